@@ -2,10 +2,13 @@ package config
 
 import (
 	"errors"
-	"fmt"
+	"os"
+	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 )
 
@@ -15,18 +18,30 @@ type Config struct {
 	Postgres PostgresConfig
 	Redis    RedisConfig
 	Weaviate WeaviateConfig
+	LLM      LLMConfig
+	Auth     AuthConfig
+	Logs     LogsConfig
+	Tracing  TracingConfig
+	// Runtime settings are reloaded while the services run, see Runtime.
+	Runtime RuntimeConfig
 }
 
+// ServerConfig durations given as bare numbers are seconds.
 type ServerConfig struct {
-	AppVersion        string
-	Port              string
-	PprofPort         string
-	Mode              string
-	JwtSecretKey      string
-	CookieName        string
-	ReadTimeout       time.Duration
-	WriteTimeout      time.Duration
-	SSL               bool
+	AppVersion   string
+	Port         string
+	PprofPort    string
+	Mode         string
+	JwtSecretKey string
+	CookieName   string
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	// SSL serves HTTPS with CertFile and KeyFile. Leave it off behind a
+	// proxy that terminates TLS.
+	SSL      bool
+	CertFile string
+	KeyFile  string
+	// CtxDefaultTimeout bounds each request's context.
 	CtxDefaultTimeout time.Duration
 	CSRF              bool
 	Debug             bool
@@ -34,18 +49,38 @@ type ServerConfig struct {
 	Timeout           time.Duration
 	MaxConnectionAge  time.Duration
 	Time              time.Duration
+	// ShutdownTimeoutSec bounds how long a stopping server waits for open
+	// streams and in-flight jobs before cutting them off.
+	ShutdownTimeoutSec int
 }
 
 type RabbitMQ struct {
-	Host           string
-	Port           string
-	User           string
-	Password       string
-	Exchange       string
-	Queue          string
-	RoutingKey     string
-	ConsumerTag    string
-	WorkerPoolSize int
+	Host        string
+	Port        string
+	User        string
+	Password    string
+	Exchange    string
+	Queue       string
+	RoutingKey  string
+	ConsumerTag string
+
+	// Partial price and stock updates bypass enrichment on their own queue.
+	UpdatesExchange    string
+	UpdatesQueue       string
+	UpdatesRoutingKey  string
+	UpdatesConsumerTag string
+
+	// Audit events of mutating operations.
+	AuditExchange    string
+	AuditQueue       string
+	AuditRoutingKey  string
+	AuditConsumerTag string
+
+	// Structured log records shipped to the log service.
+	LogsExchange    string
+	LogsQueue       string
+	LogsRoutingKey  string
+	LogsConsumerTag string
 }
 
 type PostgresConfig struct {
@@ -64,6 +99,130 @@ type WeaviateConfig struct {
 	TimeoutMs int
 }
 
+type LLMConfig struct {
+	// ContextRawTurns is how many of the latest session turns are sent
+	// verbatim alongside the running chat summary.
+	ContextRawTurns int
+	// ContextBudgets caps the prompt size per model. Models without an entry
+	// use DefaultContextBudget.
+	ContextBudgets       []ModelBudget
+	DefaultContextBudget int
+	// Prices estimates usage cost per million tokens, in PriceCurrency.
+	Prices        []ModelPrice
+	PriceCurrency string
+}
+
+type ModelPrice struct {
+	Model           string
+	PromptPer1M     float64
+	CompletionPer1M float64
+}
+
+type ModelBudget struct {
+	Model string
+	// MaxTokens is the most tokens a single call may use and ReserveTokens
+	// the share of it kept free for the completion.
+	MaxTokens     int
+	ReserveTokens int
+}
+
+// PromptBudget returns how many prompt tokens may be sent to model.
+func (l LLMConfig) PromptBudget(model string) int {
+	for _, b := range l.ContextBudgets {
+		if b.Model == model {
+			return b.MaxTokens - b.ReserveTokens
+		}
+	}
+	return l.DefaultContextBudget
+}
+
+// EstimateCost prices a call by model. Models without a price cost nothing.
+func (l LLMConfig) EstimateCost(model string, promptTokens int64, completionTokens int64) float64 {
+	for _, p := range l.Prices {
+		if p.Model == model {
+			return (float64(promptTokens)*p.PromptPer1M + float64(completionTokens)*p.CompletionPer1M) / 1e6
+		}
+	}
+	return 0
+}
+
+// QuotaConfig holds the usage limits of each plan. Orgs without an assigned
+// plan are on DefaultPlan. A limit of 0 means unlimited.
+type QuotaConfig struct {
+	DefaultPlan string
+	Plans       []PlanQuota
+}
+
+type PlanQuota struct {
+	Name                 string
+	ChatPerMinute        int
+	ChatPerMinutePerUser int
+	UploadsPerDay        int
+	MonthlyTokens        int64
+}
+
+// Plan returns the named plan, falling back to the default plan. Unknown
+// plans with no default are unlimited.
+func (q QuotaConfig) Plan(name string) PlanQuota {
+	if name == "" {
+		name = q.DefaultPlan
+	}
+	for _, p := range q.Plans {
+		if p.Name == name {
+			return p
+		}
+	}
+	for _, p := range q.Plans {
+		if p.Name == q.DefaultPlan {
+			return p
+		}
+	}
+	return PlanQuota{Name: name}
+}
+
+func (q QuotaConfig) HasPlan(name string) bool {
+	for _, p := range q.Plans {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+type AuthConfig struct {
+	// PlatformKey is the operator's key. It acts on every org with every
+	// scope and is the only key allowed to run platform-wide operations.
+	PlatformKey string
+
+	// Shopper tokens are HS256 signed with Server.JwtSecretKey or RS256
+	// signed by a key in JwksFile, a local JWK set.
+	JwksFile              string
+	JwtIssuer             string
+	AnonymousTokenMinutes int
+}
+
+// LogsConfig configures the log service.
+type LogsConfig struct {
+	Port    string
+	DataDir string
+	// Segments older than RetentionDays are deleted.
+	RetentionDays int
+	// Records are written once BatchSize have arrived or FlushIntervalMs
+	// has passed, whichever comes first.
+	BatchSize       int
+	FlushIntervalMs int
+}
+
+// TracingConfig selects where spans are exported. Exporter is "otlp" to
+// send them to an OTLP/HTTP collector at Endpoint, "file" to write them as
+// JSON to FilePath for local testing, or "none".
+type TracingConfig struct {
+	Exporter string
+	Endpoint string
+	Insecure bool
+	FilePath string
+}
+
 type RedisConfig struct {
 	RedisAddr      string
 	RedisPassword  string
@@ -94,6 +253,11 @@ func LoadConfig(filepath string) (*viper.Viper, error) {
 	v.AddConfigPath("../config")   // parent (useful for running from subfolders)
 	v.AddConfigPath("/app/config") // Docker path
 
+	// Environment variables override the file, e.g. SERVER_PORT for
+	// server.Port. Only keys present in the file can be overridden.
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
 	// Read config
 	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
@@ -108,13 +272,41 @@ func LoadConfig(filepath string) (*viper.Viper, error) {
 // Parse into struct
 func ParseConfig(v *viper.Viper) (*Config, error) {
 	var c Config
-	if err := v.Unmarshal(&c); err != nil {
+	if err := v.Unmarshal(&c, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		secondsHook(),
+		mapstructure.StringToSliceHookFunc(","),
+	))); err != nil {
 		return nil, err
 	}
 	return &c, nil
 }
 
-// high-level helper
+// secondsHook decodes durations given as bare numbers as seconds and
+// anything else with time.ParseDuration, so both "5" and "1m30s" work.
+func secondsHook() mapstructure.DecodeHookFuncType {
+	return func(_ reflect.Type, to reflect.Type, data any) (any, error) {
+		if to != reflect.TypeOf(time.Duration(0)) {
+			return data, nil
+		}
+		switch d := data.(type) {
+		case int:
+			return time.Duration(d) * time.Second, nil
+		case int64:
+			return time.Duration(d) * time.Second, nil
+		case float64:
+			return time.Duration(d * float64(time.Second)), nil
+		case string:
+			if n, err := strconv.ParseFloat(d, 64); err == nil {
+				return time.Duration(n * float64(time.Second)), nil
+			}
+			return time.ParseDuration(d)
+		}
+		return data, nil
+	}
+}
+
+// GetConfig loads the named config. It is not validated: each service
+// checks the sections it uses with ValidateProducts or ValidateLogs.
 func GetConfig(configName string) (*Config, error) {
 	path := GetConfigPath(configName)
 
@@ -126,11 +318,12 @@ func GetConfig(configName string) (*Config, error) {
 	return ParseConfig(v)
 }
 
-// helper to pick file
+// helper to pick file. CONFIG_PATH, when set, takes precedence.
 func GetConfigPath(env string) string {
-	fmt.Println("Got from env", env)
+	if path := os.Getenv("CONFIG_PATH"); path != "" {
+		return path
+	}
 	if env == "docker" {
-		fmt.Println("Returned value", "config/docker-config.yml")
 		return "config/docker-config.yml"
 	}
 	return "config/config-local.yml"
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSecondsHook(t *testing.T) {
+	hook := secondsHook()
+	durationType := reflect.TypeOf(time.Duration(0))
+
+	tests := []struct {
+		name string
+		in   any
+		want time.Duration
+	}{
+		{name: "int", in: 5, want: 5 * time.Second},
+		{name: "int64", in: int64(90), want: 90 * time.Second},
+		{name: "float", in: 1.5, want: 1500 * time.Millisecond},
+		{name: "numeric string", in: "12", want: 12 * time.Second},
+		{name: "duration string", in: "1m30s", want: 90 * time.Second},
+		{name: "milliseconds", in: "250ms", want: 250 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hook(reflect.TypeOf(tt.in), durationType, tt.in)
+			if err != nil {
+				t.Fatalf("hook(%v): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("hook(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid string", func(t *testing.T) {
+		if _, err := hook(reflect.TypeOf(""), durationType, "soon"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("other types pass through", func(t *testing.T) {
+		got, err := hook(reflect.TypeOf(5), reflect.TypeOf(0), 5)
+		if err != nil || got != 5 {
+			t.Errorf("hook = %v, %v, want 5, nil", got, err)
+		}
+	})
+}
+
+func TestGetConfigPath(t *testing.T) {
+	t.Setenv("CONFIG_PATH", "")
+	if got := GetConfigPath("docker"); got != "config/docker-config.yml" {
+		t.Errorf("GetConfigPath(docker) = %q", got)
+	}
+	if got := GetConfigPath(""); got != "config/config-local.yml" {
+		t.Errorf("GetConfigPath() = %q", got)
+	}
+
+	t.Setenv("CONFIG_PATH", "/etc/ecomm/config.yml")
+	if got := GetConfigPath("docker"); got != "/etc/ecomm/config.yml" {
+		t.Errorf("GetConfigPath(docker) with CONFIG_PATH = %q", got)
+	}
+}
+
+func TestGetConfigPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "custom.yml")
+	writeConfig(t, path, `
+server:
+  Port: ":3000"
+  ReadTimeout: 5
+  WriteTimeout: 1m
+rabbitmq:
+  Host: from-file
+runtime:
+  WorkerPoolSize: 5
+`)
+	t.Setenv("CONFIG_PATH", path)
+
+	t.Run("file values", func(t *testing.T) {
+		c, err := GetConfig("docker")
+		if err != nil {
+			t.Fatalf("GetConfig: %v", err)
+		}
+		if c.Server.Port != ":3000" || c.RabbitMQ.Host != "from-file" || c.Runtime.WorkerPoolSize != 5 {
+			t.Errorf("got port %q, host %q, pool %d from %s", c.Server.Port, c.RabbitMQ.Host, c.Runtime.WorkerPoolSize, path)
+		}
+		if c.Server.ReadTimeout != 5*time.Second || c.Server.WriteTimeout != time.Minute {
+			t.Errorf("got timeouts %v and %v, want 5s and 1m", c.Server.ReadTimeout, c.Server.WriteTimeout)
+		}
+	})
+
+	t.Run("environment overrides the file", func(t *testing.T) {
+		t.Setenv("SERVER_PORT", ":4000")
+		t.Setenv("SERVER_READTIMEOUT", "2m")
+		t.Setenv("RABBITMQ_HOST", "from-env")
+		t.Setenv("RUNTIME_WORKERPOOLSIZE", "8")
+
+		c, err := GetConfig("docker")
+		if err != nil {
+			t.Fatalf("GetConfig: %v", err)
+		}
+		if c.Server.Port != ":4000" || c.RabbitMQ.Host != "from-env" || c.Runtime.WorkerPoolSize != 8 {
+			t.Errorf("got port %q, host %q, pool %d, want the environment's", c.Server.Port, c.RabbitMQ.Host, c.Runtime.WorkerPoolSize)
+		}
+		if c.Server.ReadTimeout != 2*time.Minute {
+			t.Errorf("got read timeout %v, want 2m", c.Server.ReadTimeout)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv("CONFIG_PATH", filepath.Join(t.TempDir(), "missing.yml"))
+		if _, err := GetConfig("docker"); err == nil {
+			t.Error("expected an error for a missing file")
+		}
+	})
+}
+
+func writeConfig(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
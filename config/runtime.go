@@ -0,0 +1,207 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// RuntimeConfig holds the settings that can be tuned without a restart.
+// Everything else in Config is read once at startup.
+type RuntimeConfig struct {
+	// Worker pool sizes of the product enrichment and update consumers.
+	WorkerPoolSize        int
+	UpdatesWorkerPoolSize int
+
+	Models ModelConfig
+	Search SearchDefaults
+	Quotas QuotaConfig
+}
+
+// ModelConfig names the model used for each kind of LLM call.
+type ModelConfig struct {
+	// ChatModel answers shoppers.
+	ChatModel string
+	// SummaryModel condenses past queries and chats.
+	SummaryModel string
+	// EnrichmentModel describes product images and writes their search text.
+	EnrichmentModel string
+	// UtilityModel reranks products and extracts shopper preferences.
+	UtilityModel string
+}
+
+// SearchDefaults are the hybrid search settings of orgs that have not saved
+// their own.
+type SearchDefaults struct {
+	Alpha            float32
+	Limit            int
+	FusionType       string
+	Reranker         string
+	RerankCandidates int
+}
+
+// Runtime holds the current runtime settings. Components read Current when
+// they need a setting, or Subscribe to act on changes.
+type Runtime struct {
+	mu      sync.RWMutex
+	current RuntimeConfig
+	subs    []func(old, new RuntimeConfig)
+	log     *slog.Logger
+}
+
+func NewRuntime(initial RuntimeConfig, log *slog.Logger) *Runtime {
+	return &Runtime{current: initial, log: log}
+}
+
+func (r *Runtime) Current() RuntimeConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current
+}
+
+// Subscribe registers fn to be called after each change.
+func (r *Runtime) Subscribe(fn func(old, new RuntimeConfig)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs = append(r.subs, fn)
+}
+
+// Update replaces the runtime settings, logging each changed value. Invalid
+// settings are rejected and the current ones kept.
+func (r *Runtime) Update(next RuntimeConfig) error {
+	if err := next.Validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	old := r.current
+	r.current = next
+	subs := append([]func(old, new RuntimeConfig){}, r.subs...)
+	r.mu.Unlock()
+
+	changed := changes("runtime", reflect.ValueOf(old), reflect.ValueOf(next), nil)
+	if len(changed) == 0 {
+		return nil
+	}
+	for _, c := range changed {
+		r.log.Info("runtime setting changed", "setting", c.key, "old", fmt.Sprintf("%+v", c.old), "new", fmt.Sprintf("%+v", c.new))
+	}
+
+	for _, fn := range subs {
+		fn(old, next)
+	}
+	return nil
+}
+
+// Watch reloads the runtime section whenever the config file changes.
+// Changes to other sections are ignored until the next restart.
+func (r *Runtime) Watch(configName string) error {
+	path := GetConfigPath(configName)
+	v, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	// Editors and deploy tools often write a file in several steps, so a
+	// reload waits for the events to settle.
+	var mu sync.Mutex
+	var pending *time.Timer
+	v.OnConfigChange(func(fsnotify.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if pending != nil {
+			pending.Stop()
+		}
+		pending = time.AfterFunc(reloadDelay, func() { r.reload(path) })
+	})
+	v.WatchConfig()
+
+	r.log.Info("watching config for runtime changes", "path", path)
+	return nil
+}
+
+// reloadDelay is how long the config file must be unchanged before it is
+// reloaded.
+const reloadDelay = 250 * time.Millisecond
+
+func (r *Runtime) reload(path string) {
+	v, err := LoadConfig(path)
+	if err != nil {
+		r.log.Error("failed to reload config, keeping current runtime settings", "path", path, "err", err)
+		return
+	}
+	c, err := ParseConfig(v)
+	if err != nil {
+		r.log.Error("failed to reload config, keeping current runtime settings", "path", path, "err", err)
+		return
+	}
+	if err := r.Update(c.Runtime); err != nil {
+		r.log.Error("invalid runtime settings, keeping current ones", "path", path, "err", err)
+	}
+}
+
+// Validate checks the runtime settings on their own, for reloads.
+func (r RuntimeConfig) Validate() error {
+	v := &validator{}
+	r.validate(v)
+	return v.err()
+}
+
+func (r RuntimeConfig) validate(v *validator) {
+	v.check("runtime.WorkerPoolSize", r.WorkerPoolSize > 0, "must be positive")
+	v.check("runtime.UpdatesWorkerPoolSize", r.UpdatesWorkerPoolSize > 0, "must be positive")
+
+	v.required("runtime.models.ChatModel", r.Models.ChatModel)
+	v.required("runtime.models.SummaryModel", r.Models.SummaryModel)
+	v.required("runtime.models.EnrichmentModel", r.Models.EnrichmentModel)
+	v.required("runtime.models.UtilityModel", r.Models.UtilityModel)
+
+	s := r.Search
+	v.check("runtime.search.Alpha", s.Alpha >= 0 && s.Alpha <= 1, "must be between 0 and 1")
+	v.check("runtime.search.Limit", s.Limit > 0 && s.Limit <= 100, "must be between 1 and 100")
+	v.check("runtime.search.FusionType", s.FusionType == "ranked" || s.FusionType == "relativeScore", "must be ranked or relativeScore")
+	v.check("runtime.search.Reranker", s.Reranker == "none" || s.Reranker == "llm" || s.Reranker == "feature", "must be none, llm or feature")
+	if s.Reranker != "none" {
+		v.check("runtime.search.RerankCandidates", s.RerankCandidates >= s.Limit && s.RerankCandidates <= 100, "must be between Limit and 100")
+	}
+
+	q := r.Quotas
+	if q.DefaultPlan != "" {
+		v.check("runtime.quotas.DefaultPlan", q.HasPlan(q.DefaultPlan), fmt.Sprintf("names unknown plan %q", q.DefaultPlan))
+	}
+}
+
+type change struct {
+	key      string
+	old, new any
+}
+
+// changes lists the settings that differ between old and new, naming them
+// by their path in the config file. Lists are compared and logged whole.
+func changes(key string, old, new reflect.Value, out []change) []change {
+	if old.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(old.Interface(), new.Interface()) {
+			out = append(out, change{key: key, old: old.Interface(), new: new.Interface()})
+		}
+		return out
+	}
+
+	for i := 0; i < old.NumField(); i++ {
+		field := old.Type().Field(i)
+		name := field.Name
+		if field.Type.Kind() == reflect.Struct {
+			name = lowerFirst(name)
+		}
+		out = changes(key+"."+name, old.Field(i), new.Field(i), out)
+	}
+	return out
+}
+
+func lowerFirst(s string) string {
+	return strings.ToLower(s[:1]) + s[1:]
+}
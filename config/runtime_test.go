@@ -0,0 +1,168 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+const runtimeYAML = `
+runtime:
+  WorkerPoolSize: %d
+  UpdatesWorkerPoolSize: 10
+  models:
+    ChatModel: %s
+    SummaryModel: m
+    EnrichmentModel: m
+    UtilityModel: m
+  search:
+    Alpha: %v
+    Limit: 10
+    FusionType: ranked
+    Reranker: none
+    RerankCandidates: 30
+`
+
+func runtimeFile(pool int, chatModel string, alpha float64) string {
+	return fmt.Sprintf(runtimeYAML, pool, chatModel, alpha)
+}
+
+func TestRuntimeUpdate(t *testing.T) {
+	initial := validRuntime()
+	r := NewRuntime(initial, discardLogger())
+
+	var calls [][2]RuntimeConfig
+	r.Subscribe(func(old, new RuntimeConfig) {
+		calls = append(calls, [2]RuntimeConfig{old, new})
+	})
+
+	invalid := initial
+	invalid.WorkerPoolSize = 0
+	if err := r.Update(invalid); err == nil {
+		t.Error("Update accepted a zero worker pool")
+	}
+	if !reflect.DeepEqual(r.Current(), initial) {
+		t.Errorf("invalid update changed settings to %+v", r.Current())
+	}
+
+	if err := r.Update(initial); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("subscribers called %d times without a change", len(calls))
+	}
+
+	next := initial
+	next.WorkerPoolSize = 8
+	if err := r.Update(next); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if r.Current().WorkerPoolSize != 8 {
+		t.Errorf("WorkerPoolSize = %d, want 8", r.Current().WorkerPoolSize)
+	}
+	if len(calls) != 1 || calls[0][0].WorkerPoolSize != 5 || calls[0][1].WorkerPoolSize != 8 {
+		t.Errorf("subscriber calls = %+v, want one from 5 to 8", calls)
+	}
+}
+
+func TestRuntimeReloadKeepsPreviousOnInvalidSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	writeConfig(t, path, runtimeFile(5, "gpt-4.1", 0.8))
+
+	initial := loadRuntime(t, path)
+	r := NewRuntime(initial, discardLogger())
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "invalid value", content: runtimeFile(5, "gpt-4.1", 3)},
+		{name: "missing model", content: runtimeFile(5, `""`, 0.8)},
+		{name: "zero pool", content: runtimeFile(0, "gpt-4.1", 0.8)},
+		{name: "broken yaml", content: "runtime:\n  WorkerPoolSize: [\n"},
+		{name: "wrong type", content: runtimeFile(5, "gpt-4.1", 0.8) + "  Quotas: nope\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfig(t, path, tt.content)
+			r.reload(path)
+			if !reflect.DeepEqual(r.Current(), initial) {
+				t.Errorf("settings changed to %+v, want previous %+v", r.Current(), initial)
+			}
+		})
+	}
+
+	writeConfig(t, path, runtimeFile(7, "gpt-4o", 0.5))
+	r.reload(path)
+	got := r.Current()
+	if got.WorkerPoolSize != 7 || got.Models.ChatModel != "gpt-4o" || got.Search.Alpha != 0.5 {
+		t.Errorf("valid reload gave %+v", got)
+	}
+}
+
+func TestRuntimeWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	writeConfig(t, path, runtimeFile(5, "gpt-4.1", 0.8))
+	t.Setenv("CONFIG_PATH", path)
+
+	r := NewRuntime(loadRuntime(t, path), discardLogger())
+
+	var mu sync.Mutex
+	var sizes []int
+	r.Subscribe(func(_, new RuntimeConfig) {
+		mu.Lock()
+		defer mu.Unlock()
+		sizes = append(sizes, new.WorkerPoolSize)
+	})
+
+	if err := r.Watch(""); err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	// An invalid edit is ignored and the next valid one applied.
+	writeConfig(t, path, runtimeFile(0, "gpt-4.1", 0.8))
+	time.Sleep(2 * reloadDelay)
+	if got := r.Current().WorkerPoolSize; got != 5 {
+		t.Fatalf("WorkerPoolSize = %d after an invalid edit, want 5", got)
+	}
+
+	writeConfig(t, path, runtimeFile(9, "gpt-4.1", 0.8))
+	deadline := time.Now().Add(5 * time.Second)
+	for r.Current().WorkerPoolSize != 9 {
+		if time.Now().After(deadline) {
+			t.Fatalf("WorkerPoolSize = %d, want 9 after the edit", r.Current().WorkerPoolSize)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(sizes, []int{9}) {
+		t.Errorf("subscriber saw pool sizes %v, want [9]", sizes)
+	}
+}
+
+func loadRuntime(t *testing.T, path string) RuntimeConfig {
+	t.Helper()
+	v, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	c, err := ParseConfig(v)
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+	if err := c.Runtime.Validate(); err != nil {
+		t.Fatalf("initial runtime settings: %v", err)
+	}
+	return c.Runtime
+}
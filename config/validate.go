@@ -0,0 +1,125 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ValidateProducts checks the settings the products service uses. Every
+// problem is reported at once, so a broken deployment can be fixed in one
+// go.
+func (c *Config) ValidateProducts() error {
+	v := &validator{}
+
+	s := c.Server
+	v.address("server.Port", s.Port)
+	v.address("server.PprofPort", s.PprofPort)
+	v.check("server.ReadTimeout", s.ReadTimeout >= 0, "must not be negative")
+	v.check("server.WriteTimeout", s.WriteTimeout >= 0, "must not be negative")
+	v.check("server.CtxDefaultTimeout", s.CtxDefaultTimeout > 0, "must be positive")
+	v.check("server.ShutdownTimeoutSec", s.ShutdownTimeoutSec >= 0, "must not be negative")
+	if s.SSL {
+		v.required("server.CertFile", s.CertFile)
+		v.required("server.KeyFile", s.KeyFile)
+	}
+	v.check("server.JwtSecretKey", s.JwtSecretKey != "" || c.Auth.JwksFile != "", "is required unless auth.JwksFile is set")
+
+	r := c.RabbitMQ
+	v.rabbitmq(r)
+	v.queue("rabbitmq", "", r.Exchange, r.Queue, r.RoutingKey, r.ConsumerTag)
+	v.queue("rabbitmq", "Updates", r.UpdatesExchange, r.UpdatesQueue, r.UpdatesRoutingKey, r.UpdatesConsumerTag)
+	v.queue("rabbitmq", "Audit", r.AuditExchange, r.AuditQueue, r.AuditRoutingKey, r.AuditConsumerTag)
+	// Logs are only published here, so no consumer tag is needed.
+	v.required("rabbitmq.LogsExchange", r.LogsExchange)
+	v.required("rabbitmq.LogsQueue", r.LogsQueue)
+	v.required("rabbitmq.LogsRoutingKey", r.LogsRoutingKey)
+
+	v.required("redis.RedisAddr", c.Redis.RedisAddr)
+
+	v.required("weaviate.Host", c.Weaviate.Host)
+	v.check("weaviate.Scheme", c.Weaviate.Scheme == "http" || c.Weaviate.Scheme == "https", "must be http or https")
+
+	t := c.Tracing
+	switch strings.ToLower(t.Exporter) {
+	case "", "none":
+	case "otlp":
+		v.required("tracing.Endpoint", t.Endpoint)
+	case "file":
+		v.required("tracing.FilePath", t.FilePath)
+	default:
+		v.check("tracing.Exporter", false, "must be otlp, file or none")
+	}
+
+	c.Runtime.validate(v)
+
+	return v.err()
+}
+
+// ValidateLogs checks the settings the log service uses: its own section
+// and the queue it consumes.
+func (c *Config) ValidateLogs() error {
+	v := &validator{}
+
+	r := c.RabbitMQ
+	v.rabbitmq(r)
+	v.queue("rabbitmq", "Logs", r.LogsExchange, r.LogsQueue, r.LogsRoutingKey, r.LogsConsumerTag)
+
+	l := c.Logs
+	v.address("logs.Port", l.Port)
+	v.required("logs.DataDir", l.DataDir)
+	v.check("logs.RetentionDays", l.RetentionDays >= 0, "must not be negative")
+	v.check("logs.BatchSize", l.BatchSize > 0, "must be positive")
+	v.check("logs.FlushIntervalMs", l.FlushIntervalMs > 0, "must be positive")
+
+	return v.err()
+}
+
+type validator struct {
+	problems []error
+}
+
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid config:\n%w", errors.Join(v.problems...))
+}
+
+// check records a problem with key unless ok. Problems name the key's
+// environment override too.
+func (v *validator) check(key string, ok bool, problem string) {
+	if ok {
+		return
+	}
+	env := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	v.problems = append(v.problems, fmt.Errorf("  %s (%s) %s", key, env, problem))
+}
+
+func (v *validator) required(key, value string) {
+	v.check(key, value != "", "is required")
+}
+
+// address checks for a listen address such as ":3000".
+func (v *validator) address(key, value string) {
+	if value == "" {
+		v.required(key, value)
+		return
+	}
+	_, port, err := net.SplitHostPort(value)
+	v.check(key, err == nil && port != "", "must be host:port, e.g. :3000")
+}
+
+func (v *validator) rabbitmq(r RabbitMQ) {
+	v.required("rabbitmq.Host", r.Host)
+	v.required("rabbitmq.Port", r.Port)
+	v.required("rabbitmq.User", r.User)
+}
+
+func (v *validator) queue(section, prefix, exchange, queue, routingKey, consumerTag string) {
+	v.required(section+"."+prefix+"Exchange", exchange)
+	v.required(section+"."+prefix+"Queue", queue)
+	v.required(section+"."+prefix+"RoutingKey", routingKey)
+	v.required(section+"."+prefix+"ConsumerTag", consumerTag)
+}
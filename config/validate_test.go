@@ -0,0 +1,215 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// validProducts has every setting the products service needs.
+func validProducts() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:              ":3000",
+			PprofPort:         ":6060",
+			JwtSecretKey:      "secret",
+			CtxDefaultTimeout: 12 * time.Second,
+		},
+		RabbitMQ: validRabbitMQ(),
+		Redis:    RedisConfig{RedisAddr: "redis:6379"},
+		Weaviate: WeaviateConfig{Host: "weaviate:8080", Scheme: "http"},
+		Runtime:  validRuntime(),
+	}
+}
+
+// validLogs has only what the log service needs.
+func validLogs() *Config {
+	return &Config{
+		RabbitMQ: validRabbitMQ(),
+		Logs:     LogsConfig{Port: ":3001", DataDir: "/data/logs", BatchSize: 100, FlushIntervalMs: 500},
+	}
+}
+
+func validRabbitMQ() RabbitMQ {
+	return RabbitMQ{
+		Host: "rabbitmq", Port: "5672", User: "guest",
+		Exchange: "e", Queue: "q", RoutingKey: "k", ConsumerTag: "c",
+		UpdatesExchange: "e", UpdatesQueue: "q", UpdatesRoutingKey: "k", UpdatesConsumerTag: "c",
+		AuditExchange: "e", AuditQueue: "q", AuditRoutingKey: "k", AuditConsumerTag: "c",
+		LogsExchange: "e", LogsQueue: "q", LogsRoutingKey: "k", LogsConsumerTag: "c",
+	}
+}
+
+func validRuntime() RuntimeConfig {
+	return RuntimeConfig{
+		WorkerPoolSize:        5,
+		UpdatesWorkerPoolSize: 10,
+		Models:                ModelConfig{ChatModel: "m", SummaryModel: "m", EnrichmentModel: "m", UtilityModel: "m"},
+		Search:                SearchDefaults{Alpha: 0.8, Limit: 10, FusionType: "ranked", Reranker: "none", RerankCandidates: 30},
+		Quotas:                QuotaConfig{DefaultPlan: "free", Plans: []PlanQuota{{Name: "free"}}},
+	}
+}
+
+func TestValidateShippedConfig(t *testing.T) {
+	v, err := LoadConfig("docker-config.yml")
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	c, err := ParseConfig(v)
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+
+	if err := c.ValidateProducts(); err != nil {
+		t.Errorf("ValidateProducts: %v", err)
+	}
+	if err := c.ValidateLogs(); err != nil {
+		t.Errorf("ValidateLogs: %v", err)
+	}
+}
+
+func TestValidateProducts(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   []string
+	}{
+		{name: "valid", modify: func(c *Config) {}},
+		{
+			name:   "bad listen address",
+			modify: func(c *Config) { c.Server.Port = "3000" },
+			want:   []string{"server.Port (SERVER_PORT) must be host:port"},
+		},
+		{
+			name:   "missing jwt secret",
+			modify: func(c *Config) { c.Server.JwtSecretKey = "" },
+			want:   []string{"server.JwtSecretKey"},
+		},
+		{
+			name:   "jwks file instead of jwt secret",
+			modify: func(c *Config) { c.Server.JwtSecretKey = ""; c.Auth.JwksFile = "jwks.json" },
+		},
+		{
+			name:   "ssl without certificate",
+			modify: func(c *Config) { c.Server.SSL = true },
+			want:   []string{"server.CertFile", "server.KeyFile"},
+		},
+		{
+			name:   "missing updates queue",
+			modify: func(c *Config) { c.RabbitMQ.UpdatesQueue = "" },
+			want:   []string{"rabbitmq.UpdatesQueue (RABBITMQ_UPDATESQUEUE) is required"},
+		},
+		{
+			name:   "logs consumer tag is not needed",
+			modify: func(c *Config) { c.RabbitMQ.LogsConsumerTag = "" },
+		},
+		{
+			name:   "missing weaviate",
+			modify: func(c *Config) { c.Weaviate = WeaviateConfig{} },
+			want:   []string{"weaviate.Host", "weaviate.Scheme"},
+		},
+		{
+			name:   "unknown tracing exporter",
+			modify: func(c *Config) { c.Tracing.Exporter = "zipkin" },
+			want:   []string{"tracing.Exporter"},
+		},
+		{
+			name:   "otlp without endpoint",
+			modify: func(c *Config) { c.Tracing.Exporter = "otlp" },
+			want:   []string{"tracing.Endpoint"},
+		},
+		{
+			name:   "missing model",
+			modify: func(c *Config) { c.Runtime.Models.ChatModel = "" },
+			want:   []string{"runtime.models.ChatModel (RUNTIME_MODELS_CHATMODEL) is required"},
+		},
+		{
+			name: "reports every problem",
+			modify: func(c *Config) {
+				c.Server.CtxDefaultTimeout = 0
+				c.Redis.RedisAddr = ""
+				c.Runtime.Search.Alpha = 2
+			},
+			want: []string{"server.CtxDefaultTimeout", "redis.RedisAddr", "runtime.search.Alpha"},
+		},
+		{
+			name:   "log service settings are not needed",
+			modify: func(c *Config) { c.Logs = LogsConfig{} },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validProducts()
+			tt.modify(c)
+			checkProblems(t, c.ValidateProducts(), tt.want)
+		})
+	}
+}
+
+func TestValidateLogs(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   []string
+	}{
+		{name: "valid", modify: func(c *Config) {}},
+		{
+			name: "products service settings are not needed",
+			modify: func(c *Config) {
+				c.RabbitMQ.Queue = ""
+				c.RabbitMQ.UpdatesQueue = ""
+				c.Weaviate = WeaviateConfig{}
+				c.Runtime = RuntimeConfig{}
+			},
+		},
+		{
+			name:   "missing rabbitmq host",
+			modify: func(c *Config) { c.RabbitMQ.Host = "" },
+			want:   []string{"rabbitmq.Host"},
+		},
+		{
+			name:   "missing logs queue",
+			modify: func(c *Config) { c.RabbitMQ.LogsQueue = ""; c.RabbitMQ.LogsConsumerTag = "" },
+			want:   []string{"rabbitmq.LogsQueue", "rabbitmq.LogsConsumerTag"},
+		},
+		{
+			name: "bad logs section",
+			modify: func(c *Config) {
+				c.Logs.Port = ""
+				c.Logs.DataDir = ""
+				c.Logs.RetentionDays = -1
+				c.Logs.BatchSize = 0
+				c.Logs.FlushIntervalMs = 0
+			},
+			want: []string{"logs.Port", "logs.DataDir", "logs.RetentionDays", "logs.BatchSize", "logs.FlushIntervalMs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validLogs()
+			tt.modify(c)
+			checkProblems(t, c.ValidateLogs(), tt.want)
+		})
+	}
+}
+
+func checkProblems(t *testing.T, err error, want []string) {
+	t.Helper()
+
+	if len(want) == 0 {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil {
+		t.Fatalf("expected problems with %v, got none", want)
+	}
+	for _, w := range want {
+		if !strings.Contains(err.Error(), w) {
+			t.Errorf("error does not mention %q:\n%v", w, err)
+		}
+	}
+}
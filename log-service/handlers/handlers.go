@@ -0,0 +1,13 @@
+package handlers
+
+import "github.com/Adityadangi14/ecomm_ai/log-service/src/store"
+
+type Handlers struct {
+	LogHandler LogHandler
+}
+
+func NewHandler(logStore store.Store) *Handlers {
+	return &Handlers{
+		LogHandler: NewLogHandler(logStore),
+	}
+}
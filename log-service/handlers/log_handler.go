@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/log-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/log-service/src/store"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// tailHeartbeat keeps idle tails open through proxies and detects clients
+// that went away.
+const tailHeartbeat = 15 * time.Second
+
+type LogHandler interface {
+	ListLogs(c *fiber.Ctx) error
+	TailLogs(c *fiber.Ctx) error
+}
+
+type logHandler struct {
+	store store.Store
+}
+
+func NewLogHandler(logStore store.Store) LogHandler {
+	return &logHandler{store: logStore}
+}
+
+// ListLogs pages through stored entries, newest first. from and to are
+// RFC 3339 timestamps.
+func (h *logHandler) ListLogs(c *fiber.Ctx) error {
+	q, err := parseLogQuery(c)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	page, err := h.store.Query(q, c.Query("cursor"))
+	if errors.Is(err, store.ErrInvalidCursor) {
+		return utils.Fail(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to query logs.%v", err))
+	}
+	return utils.Success(c, page)
+}
+
+// TailLogs streams new entries matching the level, service, requestId and
+// q filters as server-sent events.
+func (h *logHandler) TailLogs(c *fiber.Ctx) error {
+	q, err := parseLogQuery(c)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, err.Error())
+	}
+	q.From, q.To = time.Time{}, time.Time{}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("Transfer-Encoding", "chunked")
+
+	entries, cancel := h.store.Subscribe()
+
+	c.Status(fiber.StatusOK).Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		heartbeat := time.NewTicker(tailHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case e, ok := <-entries:
+				if !ok {
+					return
+				}
+				if !q.Matches(e) {
+					continue
+				}
+				byt, err := json.Marshal(e)
+				if err != nil || !q.MatchesLine(string(byt)) {
+					continue
+				}
+				fmt.Fprintf(w, "id: %s\ndata: %s\n\n", e.ID, byt)
+			case <-heartbeat.C:
+				fmt.Fprintf(w, ": ping\n\n")
+			}
+
+			if err := w.Flush(); err != nil {
+				// Connection closed by client
+				return
+			}
+		}
+	}))
+
+	return nil
+}
+
+func parseLogQuery(c *fiber.Ctx) (models.LogQuery, error) {
+	q := models.LogQuery{
+		Service:   c.Query("service"),
+		RequestID: c.Query("requestId"),
+		Text:      c.Query("q"),
+		Limit:     c.QueryInt("limit", 100),
+	}
+
+	if v := c.Query("level"); v != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(v)); err != nil {
+			return q, fmt.Errorf("level must be one of DEBUG, INFO, WARN or ERROR")
+		}
+		q.Level = &level
+	}
+
+	var err error
+	if v := c.Query("from"); v != "" {
+		if q.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return q, fmt.Errorf("from must be an RFC 3339 timestamp")
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if q.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return q, fmt.Errorf("to must be an RFC 3339 timestamp")
+		}
+	}
+
+	return q, q.Validate()
+}
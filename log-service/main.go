@@ -0,0 +1,48 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/Adityadangi14/ecomm_ai/config"
+	"github.com/Adityadangi14/ecomm_ai/log-service/src/server"
+	"github.com/Adityadangi14/ecomm_ai/pkg/logger"
+	"github.com/Adityadangi14/ecomm_ai/pkg/rabbitmq"
+	"github.com/joho/godotenv"
+)
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
+func main() {
+	if err := godotenv.Load(".env"); err != nil {
+		fatal("loading env", err)
+	}
+
+	cfg, err := config.GetConfig(os.Getenv("config"))
+	if err != nil {
+		fatal("loading config", err)
+	}
+	if err := cfg.ValidateLogs(); err != nil {
+		fatal("loading config", err)
+	}
+
+	// The log service only logs locally; shipping its own records would
+	// feed them back into itself.
+	log := logger.New(cfg.Server, "log-service", nil)
+	slog.SetDefault(log)
+
+	amqpConn, err := rabbitmq.NewRabbitMQConn(cfg)
+	if err != nil {
+		fatal("connecting to rabbitmq", err)
+	}
+	defer amqpConn.Close()
+
+	s := server.NewLogServer(amqpConn, cfg, log)
+
+	if err := s.Run(); err != nil {
+		fatal("server stopped", err)
+	}
+}
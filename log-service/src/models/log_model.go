@@ -2,15 +2,29 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 )
 
+// Attribute keys lifted out of a record's attributes into their own fields.
+const (
+	ServiceKey   = "service"
+	RequestIDKey = "requestId"
+)
+
+// LogEntry is one slog record as written by slog.JSONHandler. Well-known
+// attributes get their own fields; everything else is kept in Attrs.
 type LogEntry struct {
-	Time    time.Time       `json:"time"`
-	Level   string          `json:"level"`
-	Message string          `json:"msg"`
-	Source  *SlogSource     `json:"source,omitempty"`
-	Attrs   json.RawMessage `json:"-"` // optional catch-all if you want dynamic fields
+	ID        string         `json:"id,omitempty"`
+	Time      time.Time      `json:"time"`
+	Level     string         `json:"level"`
+	Message   string         `json:"msg"`
+	Service   string         `json:"service,omitempty"`
+	RequestID string         `json:"requestId,omitempty"`
+	Source    *SlogSource    `json:"source,omitempty"`
+	Attrs     map[string]any `json:"attrs,omitempty"`
 }
 
 type SlogSource struct {
@@ -18,3 +32,104 @@ type SlogSource struct {
 	File     string `json:"file"`
 	Line     int    `json:"line"`
 }
+
+// UnmarshalJSON accepts both the flat slog.JSONHandler layout and the
+// stored layout, where extra attributes are nested under "attrs".
+func (e *LogEntry) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	fields := []struct {
+		key string
+		dst any
+	}{
+		{"id", &e.ID},
+		{slog.TimeKey, &e.Time},
+		{slog.LevelKey, &e.Level},
+		{slog.MessageKey, &e.Message},
+		{ServiceKey, &e.Service},
+		{RequestIDKey, &e.RequestID},
+		{slog.SourceKey, &e.Source},
+		{"attrs", &e.Attrs},
+	}
+	for _, f := range fields {
+		v, ok := raw[f.key]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(v, f.dst); err != nil {
+			return fmt.Errorf("invalid %s: %v", f.key, err)
+		}
+		delete(raw, f.key)
+	}
+
+	for k, v := range raw {
+		var val any
+		if err := json.Unmarshal(v, &val); err != nil {
+			return err
+		}
+		if e.Attrs == nil {
+			e.Attrs = make(map[string]any, len(raw))
+		}
+		e.Attrs[k] = val
+	}
+	return nil
+}
+
+// LogQuery filters stored entries. Level is a minimum: "WARN" matches
+// warnings and errors. Text matches the message and attributes, ignoring
+// case.
+type LogQuery struct {
+	Level     *slog.Level
+	Service   string
+	RequestID string
+	From      time.Time
+	To        time.Time
+	Text      string
+	Limit     int
+}
+
+func (q LogQuery) Validate() error {
+	if q.Limit <= 0 || q.Limit > 1000 {
+		return fmt.Errorf("limit must be between 1 and 1000")
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && q.To.Before(q.From) {
+		return fmt.Errorf("from must not be after to")
+	}
+	return nil
+}
+
+// MatchesLine is a cheap pre-filter on an encoded entry, applied before it
+// is decoded.
+func (q LogQuery) MatchesLine(line string) bool {
+	return q.Text == "" || strings.Contains(strings.ToLower(line), strings.ToLower(q.Text))
+}
+
+func (q LogQuery) Matches(e LogEntry) bool {
+	if q.Level != nil {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(e.Level)); err != nil || level < *q.Level {
+			return false
+		}
+	}
+	if q.Service != "" && e.Service != q.Service {
+		return false
+	}
+	if q.RequestID != "" && e.RequestID != q.RequestID {
+		return false
+	}
+	if !q.From.IsZero() && e.Time.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && e.Time.After(q.To) {
+		return false
+	}
+	return true
+}
+
+type LogPage struct {
+	Entries    []LogEntry `json:"entries"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
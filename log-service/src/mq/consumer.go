@@ -0,0 +1,198 @@
+package mq
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/log-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/log-service/src/store"
+	"github.com/pkg/errors"
+	"github.com/streadway/amqp"
+)
+
+const (
+	exchangeKind       = "direct"
+	exchangeDurable    = true
+	exchangeAutoDelete = false
+	exchangeInternal   = false
+	exchangeNoWait     = false
+
+	queueDurable    = true
+	queueAutoDelete = false
+	queueExclusive  = false
+	queueNoWait     = false
+
+	prefetchSize   = 0
+	prefetchGlobal = false
+
+	consumeAutoAck   = false
+	consumeExclusive = false
+	consumeNoLocal   = false
+	consumeNoWait    = false
+)
+
+// LogConsumer writes shipped log records to the store in batches.
+type LogConsumer struct {
+	amqpConn      *amqp.Connection
+	store         store.Store
+	batchSize     int
+	flushInterval time.Duration
+	log           *slog.Logger
+}
+
+func NewLogConsumer(amqpConn *amqp.Connection, logStore store.Store, batchSize int, flushInterval time.Duration, log *slog.Logger) *LogConsumer {
+	if batchSize <= 0 {
+		batchSize = 200
+	}
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+	return &LogConsumer{amqpConn: amqpConn, store: logStore, batchSize: batchSize, flushInterval: flushInterval, log: log}
+}
+
+func (l *LogConsumer) CreateChannel(exchangeName, queueName, bindingKey string) (*amqp.Channel, error) {
+	ch, err := l.amqpConn.Channel()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error amqpConn.Channel")
+	}
+
+	err = ch.ExchangeDeclare(
+		exchangeName,
+		exchangeKind,
+		exchangeDurable,
+		exchangeAutoDelete,
+		exchangeInternal,
+		exchangeNoWait,
+		nil,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error ch.ExchangeDeclare")
+	}
+
+	queue, err := ch.QueueDeclare(
+		queueName,
+		queueDurable,
+		queueAutoDelete,
+		queueExclusive,
+		queueNoWait,
+		nil,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error ch.QueueDeclare")
+	}
+
+	err = ch.QueueBind(
+		queue.Name,
+		bindingKey,
+		exchangeName,
+		queueNoWait,
+		nil,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error ch.QueueBind")
+	}
+
+	// Allow a full batch in flight on top of the one being written.
+	err = ch.Qos(
+		l.batchSize*2,
+		prefetchSize,
+		prefetchGlobal,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error  ch.Qos")
+	}
+
+	return ch, nil
+}
+
+// StartConsumer consumes the logs queue until the channel closes. Records
+// are written once batchSize deliveries have arrived or flushInterval has
+// passed, and acknowledged only after the write.
+func (l *LogConsumer) StartConsumer(exchange, queueName, bindingKey, consumerTag string) error {
+	ch, err := l.CreateChannel(exchange, queueName, bindingKey)
+	if err != nil {
+		return errors.Wrap(err, "CreateChannel")
+	}
+	defer ch.Close()
+
+	deliveries, err := ch.Consume(
+		queueName,
+		consumerTag,
+		consumeAutoAck,
+		consumeExclusive,
+		consumeNoLocal,
+		consumeNoWait,
+		nil,
+	)
+	if err != nil {
+		return errors.Wrap(err, "Consume")
+	}
+
+	ticker := time.NewTicker(l.flushInterval)
+	defer ticker.Stop()
+
+	var batch []amqp.Delivery
+	for {
+		select {
+		case d, ok := <-deliveries:
+			if !ok {
+				l.flush(batch)
+				return errors.New("logs channel closed")
+			}
+			batch = append(batch, d)
+			if len(batch) >= l.batchSize {
+				l.flush(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			l.flush(batch)
+			batch = nil
+		}
+	}
+}
+
+func (l *LogConsumer) flush(batch []amqp.Delivery) {
+	if len(batch) == 0 {
+		return
+	}
+	last := batch[len(batch)-1]
+
+	var entries []models.LogEntry
+	for _, d := range batch {
+		entries = append(entries, l.decodeEntries(d.Body)...)
+	}
+
+	if err := l.store.Append(entries); err != nil {
+		l.log.Error("log write failed", "entries", len(entries), "err", err)
+		if err := last.Nack(true, true); err != nil {
+			l.log.Error("nack failed", "err", err)
+		}
+		return
+	}
+
+	if err := last.Ack(true); err != nil {
+		l.log.Error("ack failed", "err", err)
+	}
+}
+
+// decodeEntries reads one record per line, so a publisher may send a
+// single record or several. Malformed records are dropped.
+func (l *LogConsumer) decodeEntries(body []byte) []models.LogEntry {
+	var entries []models.LogEntry
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var e models.LogEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			l.log.Warn("invalid log record", "err", err)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
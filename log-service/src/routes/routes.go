@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/Adityadangi14/ecomm_ai/log-service/handlers"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterRoutes(app *fiber.App, handlers handlers.Handlers, platformKey string) {
+	logs := app.Group("/logs", requireKey(platformKey))
+
+	logs.Get("/", handlers.LogHandler.ListLogs)
+	logs.Get("/tail", handlers.LogHandler.TailLogs)
+}
+
+// requireKey admits only the operator's platform key, sent as
+// "Authorization: Bearer <key>" or X-API-Key. Logs span every org.
+func requireKey(platformKey string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !ok {
+			key = c.Get("X-API-Key")
+		}
+		key = strings.TrimSpace(key)
+
+		if platformKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(platformKey)) != 1 {
+			return utils.Fail(c, fiber.StatusUnauthorized, "invalid or missing key")
+		}
+		return c.Next()
+	}
+}
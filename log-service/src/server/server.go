@@ -0,0 +1,78 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/config"
+	"github.com/Adityadangi14/ecomm_ai/log-service/handlers"
+	"github.com/Adityadangi14/ecomm_ai/log-service/src/mq"
+	"github.com/Adityadangi14/ecomm_ai/log-service/src/routes"
+	"github.com/Adityadangi14/ecomm_ai/log-service/src/store"
+	"github.com/gofiber/fiber/v2"
+	"github.com/streadway/amqp"
+)
+
+// pruneInterval is how often expired segments are deleted.
+const pruneInterval = time.Hour
+
+type Server struct {
+	amqp *amqp.Connection
+	cfg  *config.Config
+	log  *slog.Logger
+}
+
+func NewLogServer(mq *amqp.Connection, cfg *config.Config, log *slog.Logger) *Server {
+	return &Server{amqp: mq, cfg: cfg, log: log}
+}
+
+func (s *Server) Run() error {
+	logStore, err := store.NewFileStore(s.cfg.Logs.DataDir)
+	if err != nil {
+		return fmt.Errorf("failed to open log store:%v", err)
+	}
+	defer logStore.Close()
+
+	consumer := mq.NewLogConsumer(s.amqp, logStore, s.cfg.Logs.BatchSize,
+		time.Duration(s.cfg.Logs.FlushIntervalMs)*time.Millisecond, s.log)
+
+	go func() {
+		err := consumer.StartConsumer(
+			s.cfg.RabbitMQ.LogsExchange,
+			s.cfg.RabbitMQ.LogsQueue,
+			s.cfg.RabbitMQ.LogsRoutingKey,
+			s.cfg.RabbitMQ.LogsConsumerTag,
+		)
+
+		if err != nil {
+			s.log.Error("failed to start logs consumer", "err", err)
+		}
+	}()
+
+	go s.prune(logStore)
+
+	app := fiber.New()
+
+	apiHandler := handlers.NewHandler(logStore)
+
+	routes.RegisterRoutes(app, *apiHandler, s.cfg.Auth.PlatformKey)
+
+	return app.Listen(s.cfg.Logs.Port)
+}
+
+func (s *Server) prune(logStore store.Store) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for ; ; <-ticker.C {
+		removed, err := logStore.Prune(s.cfg.Logs.RetentionDays)
+		if err != nil {
+			s.log.Error("failed to prune log segments", "err", err)
+			continue
+		}
+		if removed > 0 {
+			s.log.Info("pruned log segments", "removed", removed)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/log-service/src/models"
+	"github.com/pkg/errors"
+)
+
+const (
+	segmentExt    = ".jsonl"
+	segmentLayout = "2006-01-02"
+
+	// maxLineBytes bounds a single stored record. Larger records keep
+	// maxMessageBytes of their message and lose their attributes; even
+	// fully escaped that stays well under the line limit.
+	maxLineBytes    = 1 << 20
+	maxMessageBytes = 64 << 10
+
+	// subscriberBuffer is how many entries a slow tail may fall behind
+	// before entries are dropped for it.
+	subscriberBuffer = 256
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type Store interface {
+	// Append durably writes entries and assigns their IDs.
+	Append(entries []models.LogEntry) error
+	// Query returns matching entries newest first. cursor is the
+	// NextCursor of the previous page.
+	Query(q models.LogQuery, cursor string) (models.LogPage, error)
+	// Subscribe streams entries as they are appended until cancel is
+	// called.
+	Subscribe() (entries <-chan models.LogEntry, cancel func())
+	// Prune deletes segments older than retentionDays and returns how many
+	// were removed.
+	Prune(retentionDays int) (int, error)
+	Close() error
+}
+
+// fileStore keeps one append-only JSONL segment per UTC day. An entry's ID
+// is its segment date and line number, which also orders it.
+type fileStore struct {
+	dir string
+
+	mu       sync.Mutex
+	segments map[string]*segment
+
+	subMu       sync.Mutex
+	subscribers map[chan models.LogEntry]struct{}
+}
+
+type segment struct {
+	file  *os.File
+	lines int
+	size  int64
+}
+
+func NewFileStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "os.MkdirAll")
+	}
+	return &fileStore{
+		dir:         dir,
+		segments:    map[string]*segment{},
+		subscribers: map[chan models.LogEntry]struct{}{},
+	}, nil
+}
+
+func (s *fileStore) Append(entries []models.LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Group by day, keeping arrival order within a day.
+	byDay := map[string][]int{}
+	var days []string
+	for i := range entries {
+		if entries[i].Time.IsZero() {
+			entries[i].Time = time.Now()
+		}
+		day := entries[i].Time.UTC().Format(segmentLayout)
+		if _, ok := byDay[day]; !ok {
+			days = append(days, day)
+		}
+		byDay[day] = append(byDay[day], i)
+	}
+
+	// Encode everything before writing anything, so a bad entry cannot
+	// leave part of the batch on disk.
+	writes := make([]segmentWrite, 0, len(days))
+	for _, day := range days {
+		seg, err := s.segment(day)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		lines := seg.lines
+		for _, i := range byDay[day] {
+			lines++
+			entries[i].ID = entryID(day, lines)
+
+			byt, err := encodeLine(&entries[i])
+			if err != nil {
+				return err
+			}
+			buf.Write(byt)
+			buf.WriteByte('\n')
+		}
+		writes = append(writes, segmentWrite{seg: seg, day: day, data: buf.Bytes(), lines: lines})
+	}
+
+	// A failed batch is redelivered whole, so days already written are
+	// rolled back rather than stored twice.
+	for n, w := range writes {
+		if err := w.write(); err != nil {
+			return s.rollback(writes[:n+1], err)
+		}
+	}
+	for _, w := range writes {
+		w.seg.lines = w.lines
+		w.seg.size += int64(len(w.data))
+	}
+
+	s.broadcast(entries)
+	return nil
+}
+
+type segmentWrite struct {
+	seg   *segment
+	day   string
+	data  []byte
+	lines int
+}
+
+func (w segmentWrite) write() error {
+	if _, err := w.seg.file.Write(w.data); err != nil {
+		return errors.Wrap(err, "segment write")
+	}
+	if err := w.seg.file.Sync(); err != nil {
+		return errors.Wrap(err, "segment sync")
+	}
+	return nil
+}
+
+// rollback truncates segments back to their size before the failed batch.
+// A segment that cannot be truncated is closed so its lines are recounted
+// when it is next opened.
+func (s *fileStore) rollback(writes []segmentWrite, cause error) error {
+	err := cause
+	for _, w := range writes {
+		if truncErr := w.seg.file.Truncate(w.seg.size); truncErr != nil {
+			w.seg.file.Close()
+			delete(s.segments, w.day)
+			err = errors.Wrapf(err, "rollback of %s failed: %v", w.day, truncErr)
+		}
+	}
+	return err
+}
+
+// encodeLine marshals an entry, cutting its message and dropping its
+// attributes when the line would not fit in maxLineBytes.
+func encodeLine(e *models.LogEntry) ([]byte, error) {
+	byt, err := json.Marshal(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "json.Marshal")
+	}
+	if len(byt) < maxLineBytes {
+		return byt, nil
+	}
+
+	if len(e.Message) > maxMessageBytes {
+		e.Message = strings.ToValidUTF8(e.Message[:maxMessageBytes], "") + "..."
+	}
+	e.Attrs = map[string]any{"truncated": true, "originalBytes": len(byt)}
+
+	byt, err = json.Marshal(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "json.Marshal")
+	}
+	return byt, nil
+}
+
+// segment opens the day's segment for appending, counting the lines
+// already in it so new IDs continue from there.
+func (s *fileStore) segment(day string) (*segment, error) {
+	if seg, ok := s.segments[day]; ok {
+		return seg, nil
+	}
+
+	path := s.segmentPath(day)
+	lines := 0
+	err := scanLines(path, func(int, string) bool { lines++; return true })
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, errors.Wrap(err, "os.OpenFile")
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "file.Stat")
+	}
+
+	seg := &segment{file: f, lines: lines, size: info.Size()}
+	s.segments[day] = seg
+	return seg, nil
+}
+
+func (s *fileStore) Query(q models.LogQuery, cursor string) (models.LogPage, error) {
+	page := models.LogPage{Entries: []models.LogEntry{}}
+
+	cursorDay, cursorLine := "", 0
+	if cursor != "" {
+		var err error
+		if cursorDay, cursorLine, err = parseEntryID(cursor); err != nil {
+			return page, err
+		}
+	}
+
+	days, err := s.days()
+	if err != nil {
+		return page, err
+	}
+
+	for i := len(days) - 1; i >= 0 && len(page.Entries) < q.Limit; i-- {
+		day := days[i]
+		if !q.To.IsZero() && day > q.To.UTC().Format(segmentLayout) {
+			continue
+		}
+		if !q.From.IsZero() && day < q.From.UTC().Format(segmentLayout) {
+			break
+		}
+		if cursorDay != "" && day > cursorDay {
+			continue
+		}
+
+		// Only the newest matches of a segment can make the page, so keep
+		// at most as many as are still needed.
+		want := q.Limit - len(page.Entries)
+		var matches []models.LogEntry
+
+		err := scanLines(s.segmentPath(day), func(n int, line string) bool {
+			if day == cursorDay && n >= cursorLine {
+				return false
+			}
+			if !q.MatchesLine(line) {
+				return true
+			}
+
+			var e models.LogEntry
+			if err := json.Unmarshal([]byte(line), &e); err != nil || !q.Matches(e) {
+				return true
+			}
+			e.ID = entryID(day, n)
+
+			matches = append(matches, e)
+			if len(matches) > want {
+				matches = matches[1:]
+			}
+			return true
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return page, err
+		}
+
+		for j := len(matches) - 1; j >= 0; j-- {
+			page.Entries = append(page.Entries, matches[j])
+		}
+	}
+
+	if len(page.Entries) == q.Limit {
+		page.NextCursor = page.Entries[len(page.Entries)-1].ID
+	}
+	return page, nil
+}
+
+func (s *fileStore) Subscribe() (<-chan models.LogEntry, func()) {
+	ch := make(chan models.LogEntry, subscriberBuffer)
+
+	s.subMu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.subMu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.subMu.Lock()
+			delete(s.subscribers, ch)
+			s.subMu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// broadcast never blocks ingestion: a subscriber that is not keeping up
+// misses entries.
+func (s *fileStore) broadcast(entries []models.LogEntry) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	for ch := range s.subscribers {
+		for _, e := range entries {
+			select {
+			case ch <- e:
+			default:
+			}
+		}
+	}
+}
+
+func (s *fileStore) Prune(retentionDays int) (int, error) {
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().UTC().AddDate(0, 0, -retentionDays).Format(segmentLayout)
+
+	days, err := s.days()
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for _, day := range days {
+		if day >= cutoff {
+			break
+		}
+		if seg, ok := s.segments[day]; ok {
+			seg.file.Close()
+			delete(s.segments, day)
+		}
+		if err := os.Remove(s.segmentPath(day)); err != nil && !os.IsNotExist(err) {
+			return removed, errors.Wrap(err, "os.Remove")
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func (s *fileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+	for day, seg := range s.segments {
+		if err := seg.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(s.segments, day)
+	}
+	return firstErr
+}
+
+// days lists the segment dates on disk, oldest first.
+func (s *fileStore) days() ([]string, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "os.ReadDir")
+	}
+
+	var days []string
+	for _, f := range files {
+		day, ok := strings.CutSuffix(f.Name(), segmentExt)
+		if !ok || f.IsDir() {
+			continue
+		}
+		if _, err := time.Parse(segmentLayout, day); err != nil {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	return days, nil
+}
+
+func (s *fileStore) segmentPath(day string) string {
+	return filepath.Join(s.dir, day+segmentExt)
+}
+
+// scanLines calls fn with each line of the file and its 1-based number
+// until fn returns false.
+func scanLines(path string, fn func(n int, line string) bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+
+	n := 0
+	for scanner.Scan() {
+		n++
+		if !fn(n, scanner.Text()) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+func entryID(day string, line int) string {
+	return fmt.Sprintf("%s-%d", day, line)
+}
+
+func parseEntryID(id string) (string, int, error) {
+	i := strings.LastIndex(id, "-")
+	if i < 0 {
+		return "", 0, ErrInvalidCursor
+	}
+	day := id[:i]
+	line, err := strconv.Atoi(id[i+1:])
+	if _, dayErr := time.Parse(segmentLayout, day); dayErr != nil || err != nil || line <= 0 {
+		return "", 0, ErrInvalidCursor
+	}
+	return day, line, nil
+}
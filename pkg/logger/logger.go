@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys every service uses for correlation. RequestIDKey and
+// ServiceKey match the fields the log service indexes.
+const (
+	ServiceKey   = "service"
+	RequestIDKey = "requestId"
+	OrgIDKey     = "orgId"
+	JobIDKey     = "jobId"
+	TraceIDKey   = "traceId"
+	SpanIDKey    = "spanId"
+)
+
+// New builds the service's logger from the server config: JSON in docker
+// and production, text otherwise, and debug records only with Debug set.
+// When ship is not nil every record is also written to it as one
+// slog.JSONHandler line, the log service's LogEntry format.
+func New(cfg config.ServerConfig, service string, ship io.Writer) *slog.Logger {
+	level := slog.LevelInfo
+	if cfg.Debug {
+		level = slog.LevelDebug
+	}
+
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redact}
+
+	var handler slog.Handler
+	if jsonMode(cfg.Mode) {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	}
+
+	if ship != nil {
+		handler = fanout{handler, slog.NewJSONHandler(ship, &slog.HandlerOptions{
+			AddSource:   true,
+			Level:       level,
+			ReplaceAttr: redact,
+		})}
+	}
+
+	return slog.New(contextHandler{handler}).With(ServiceKey, service)
+}
+
+func jsonMode(mode string) bool {
+	switch strings.ToLower(mode) {
+	case "docker", "production":
+		return true
+	}
+	return false
+}
+
+type ctxKey struct{}
+
+// With returns a context whose records carry args as attributes, in
+// addition to any added by an enclosing With.
+func With(ctx context.Context, args ...any) context.Context {
+	attrs, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+	next := make([]slog.Attr, len(attrs), len(attrs)+len(args)/2)
+	copy(next, attrs)
+
+	r := slog.NewRecord(time.Time{}, 0, "", 0)
+	r.Add(args...)
+	r.Attrs(func(a slog.Attr) bool {
+		next = append(next, a)
+		return true
+	})
+	return context.WithValue(ctx, ctxKey{}, next)
+}
+
+// contextHandler adds the attributes stored by With, and the IDs of the
+// active span, to each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(ctxKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String(TraceIDKey, sc.TraceID().String()), slog.String(SpanIDKey, sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// fanout writes each record to every handler that accepts its level.
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, r slog.Record) error {
+	var firstErr error
+	for _, h := range f {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := make(fanout, len(f))
+	for i, h := range f {
+		next[i] = h.WithAttrs(attrs)
+	}
+	return next
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	next := make(fanout, len(f))
+	for i, h := range f {
+		next[i] = h.WithGroup(name)
+	}
+	return next
+}
@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/config"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/streadway/amqp"
+)
+
+const (
+	// shipBuffer is how many records may wait to be published. Records are
+	// dropped rather than blocking the caller once it is full.
+	shipBuffer = 4096
+	// shipBatch records are sent per message, or whatever arrived within
+	// shipInterval.
+	shipBatch    = 100
+	shipInterval = 500 * time.Millisecond
+)
+
+// Shipper publishes log lines to the logs exchange in batches. Each
+// message body holds one record per line.
+type Shipper struct {
+	ch       *amqp.Channel
+	exchange string
+	key      string
+
+	lines   chan []byte
+	done    chan struct{}
+	closeMu sync.Once
+}
+
+func NewShipper(conn *amqp.Connection, cfg config.RabbitMQ) (*Shipper, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error amqpConn.Channel")
+	}
+
+	if err := ch.ExchangeDeclare(cfg.LogsExchange, "direct", true, false, false, false, nil); err != nil {
+		return nil, errors.Wrap(err, "Error ch.ExchangeDeclare")
+	}
+	queue, err := ch.QueueDeclare(cfg.LogsQueue, true, false, false, false, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error ch.QueueDeclare")
+	}
+	if err := ch.QueueBind(queue.Name, cfg.LogsRoutingKey, cfg.LogsExchange, false, nil); err != nil {
+		return nil, errors.Wrap(err, "Error ch.QueueBind")
+	}
+
+	s := &Shipper{
+		ch:       ch,
+		exchange: cfg.LogsExchange,
+		key:      cfg.LogsRoutingKey,
+		lines:    make(chan []byte, shipBuffer),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Write queues one encoded record. It never blocks and never fails, so
+// logging cannot stall or break a request.
+func (s *Shipper) Write(p []byte) (int, error) {
+	line := bytes.Clone(p)
+	select {
+	case s.lines <- line:
+	default:
+	}
+	return len(p), nil
+}
+
+// Close publishes queued records and closes the channel.
+func (s *Shipper) Close() error {
+	s.closeMu.Do(func() { close(s.lines) })
+	<-s.done
+	return s.ch.Close()
+}
+
+func (s *Shipper) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(shipInterval)
+	defer ticker.Stop()
+
+	var batch bytes.Buffer
+	n := 0
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				s.publish(&batch)
+				return
+			}
+			batch.Write(line)
+			n++
+			if n < shipBatch {
+				continue
+			}
+		case <-ticker.C:
+		}
+		s.publish(&batch)
+		n = 0
+	}
+}
+
+// publish sends the batch. Failures go to stderr: logging them through
+// slog would feed them back into the shipper.
+func (s *Shipper) publish(batch *bytes.Buffer) {
+	if batch.Len() == 0 {
+		return
+	}
+	defer batch.Reset()
+
+	err := s.ch.Publish(s.exchange, s.key, false, false, amqp.Publishing{
+		ContentType:  "application/x-ndjson",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    uuid.New().String(),
+		Timestamp:    time.Now(),
+		Body:         bytes.Clone(batch.Bytes()),
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to ship logs:", err)
+	}
+}
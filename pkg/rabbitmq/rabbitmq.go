@@ -2,6 +2,7 @@ package rabbitmq
 
 import (
 	"fmt"
+	"log/slog"
 
 	"github.com/Adityadangi14/ecomm_ai/config"
 	"github.com/streadway/amqp"
@@ -16,6 +17,6 @@ func NewRabbitMQConn(cfg *config.Config) (*amqp.Connection, error) {
 		cfg.RabbitMQ.Host,
 		cfg.RabbitMQ.Port,
 	)
-	fmt.Println("conn adder", connAddr)
+	slog.Info("connecting to rabbitmq", "host", cfg.RabbitMQ.Host, "port", cfg.RabbitMQ.Port, "user", cfg.RabbitMQ.User)
 	return amqp.Dial(connAddr)
 }
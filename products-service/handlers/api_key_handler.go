@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/audit"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type APIKeyHandler interface {
+	IssueKey(c *fiber.Ctx) error
+	ListKeys(c *fiber.Ctx) error
+	RotateKey(c *fiber.Ctx) error
+	RevokeKey(c *fiber.Ctx) error
+}
+
+type apiKeyHandler struct {
+	rdb   *redis.Client
+	audit audit.Recorder
+}
+
+func NewAPIKeyHandler(rdb *redis.Client, recorder audit.Recorder) APIKeyHandler {
+	return &apiKeyHandler{rdb: rdb, audit: recorder}
+}
+
+func (h *apiKeyHandler) IssueKey(c *fiber.Ctx) error {
+	var req models.IssueAPIKeyRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("failed to parse body: %v", err))
+	}
+
+	if err := req.Validate(); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	issued, err := h.issue(ctx, models.APIKey{
+		OrgID:     c.Params("orgId"),
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to issue api key.%v", err))
+	}
+	h.audit.Record(c, models.AuditAPIKeyIssue, issued.ID, nil, issued.APIKey)
+
+	return utils.Success(c, issued)
+}
+
+func (h *apiKeyHandler) ListKeys(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	keys, err := helpers.ListAPIKeys(ctx, h.rdb, c.Params("orgId"))
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to list api keys.%v", err))
+	}
+	return utils.Success(c, keys)
+}
+
+// RotateKey revokes a key and issues a replacement with the same name,
+// scopes, expiry and role.
+func (h *apiKeyHandler) RotateKey(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	old, status, err := h.orgKey(ctx, c)
+	if err != nil {
+		return utils.Fail(c, status, err.Error())
+	}
+	if old.RevokedAt != nil {
+		return utils.Fail(c, fiber.StatusConflict, "api key is already revoked")
+	}
+
+	issued, err := h.issue(ctx, models.APIKey{
+		OrgID:     old.OrgID,
+		Name:      old.Name,
+		Scopes:    old.Scopes,
+		ExpiresAt: old.ExpiresAt,
+	})
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to issue api key.%v", err))
+	}
+
+	assignment, err := helpers.GetRoleAssignment(ctx, h.rdb, old.OrgID, models.KeyPrincipal(old.ID))
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to get role.%v", err))
+	}
+	if assignment != nil {
+		assignment.Principal = models.KeyPrincipal(issued.ID)
+		if err := helpers.SetRoleAssignment(ctx, h.rdb, old.OrgID, *assignment); err != nil {
+			return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to assign role.%v", err))
+		}
+	}
+
+	if err := h.revoke(ctx, old); err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to revoke api key.%v", err))
+	}
+	h.audit.Record(c, models.AuditAPIKeyRotate, old.ID, old, issued.APIKey)
+
+	return utils.Success(c, issued)
+}
+
+func (h *apiKeyHandler) RevokeKey(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	key, status, err := h.orgKey(ctx, c)
+	if err != nil {
+		return utils.Fail(c, status, err.Error())
+	}
+
+	if key.RevokedAt == nil {
+		if err := h.revoke(ctx, key); err != nil {
+			return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to revoke api key.%v", err))
+		}
+		h.audit.Record(c, models.AuditAPIKeyRevoke, key.ID, nil, nil)
+	}
+
+	return utils.Success(c, "api key revoked")
+}
+
+func (h *apiKeyHandler) issue(ctx context.Context, key models.APIKey) (models.IssuedAPIKey, error) {
+	id, plaintext, err := helpers.GenerateAPIKey()
+	if err != nil {
+		return models.IssuedAPIKey{}, err
+	}
+
+	key.ID = id
+	key.Prefix = plaintext[:len("ek_")+len(id)]
+	key.CreatedAt = time.Now()
+
+	if err := helpers.SaveAPIKey(ctx, h.rdb, key, helpers.HashAPIKey(plaintext)); err != nil {
+		return models.IssuedAPIKey{}, err
+	}
+	return models.IssuedAPIKey{APIKey: key, Key: plaintext}, nil
+}
+
+// revoke revokes the key and drops its role assignment.
+func (h *apiKeyHandler) revoke(ctx context.Context, key models.APIKey) error {
+	if err := helpers.RevokeAPIKey(ctx, h.rdb, key.ID); err != nil {
+		return err
+	}
+	_, err := helpers.DeleteRoleAssignment(ctx, h.rdb, key.OrgID, models.KeyPrincipal(key.ID))
+	return err
+}
+
+// orgKey loads the key named in the path, hiding keys of other orgs.
+func (h *apiKeyHandler) orgKey(ctx context.Context, c *fiber.Ctx) (models.APIKey, int, error) {
+	key, _, err := helpers.GetAPIKey(ctx, h.rdb, c.Params("keyId"))
+	if errors.Is(err, helpers.ErrAPIKeyNotFound) || (err == nil && key.OrgID != c.Params("orgId")) {
+		return models.APIKey{}, fiber.StatusNotFound, errors.New("api key not found")
+	}
+	if err != nil {
+		return models.APIKey{}, fiber.StatusInternalServerError, fmt.Errorf("unable to get api key.%v", err)
+	}
+	return key, 0, nil
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type AuditHandler interface {
+	ListEvents(c *fiber.Ctx) error
+}
+
+type auditHandler struct {
+	rdb *redis.Client
+}
+
+func NewAuditHandler(rdb *redis.Client) AuditHandler {
+	return &auditHandler{rdb: rdb}
+}
+
+// ListEvents pages through the org's audit log, newest first. from and to
+// are RFC 3339 timestamps; actor is a principal such as "key:<id>".
+func (h *auditHandler) ListEvents(c *fiber.Ctx) error {
+	q := models.AuditQuery{
+		OrgID: c.Params("orgId"),
+		Actor: c.Query("actor"),
+		Limit: c.QueryInt("limit", 50),
+	}
+
+	var err error
+	if v := c.Query("from"); v != "" {
+		if q.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return utils.Fail(c, fiber.StatusBadRequest, "from must be an RFC 3339 timestamp")
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if q.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return utils.Fail(c, fiber.StatusBadRequest, "to must be an RFC 3339 timestamp")
+		}
+	}
+
+	var cursor models.AuditCursor
+	if v := c.Query("cursor"); v != "" {
+		if cursor, err = models.ParseAuditCursor(v); err != nil {
+			return utils.Fail(c, fiber.StatusBadRequest, err.Error())
+		}
+	}
+
+	if err := q.Validate(); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	page, err := helpers.QueryAuditEvents(ctx, h.rdb, q, cursor)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to get audit events.%v", err))
+	}
+	return utils.Success(c, page)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/auth"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuthHandler interface {
+	IssueAnonymousToken(c *fiber.Ctx) error
+}
+
+type authHandler struct {
+	authn auth.Authenticator
+}
+
+func NewAuthHandler(authn auth.Authenticator) AuthHandler {
+	return &authHandler{authn: authn}
+}
+
+// IssueAnonymousToken gives the chat widget a short-lived token for a new
+// anonymous shopper and session of the calling key's org.
+func (h *authHandler) IssueAnonymousToken(c *fiber.Ctx) error {
+	if auth.IsShopper(c) {
+		return utils.Fail(c, fiber.StatusForbidden, "anonymous tokens must be requested with an api key")
+	}
+
+	orgID := auth.OrgID(c)
+	if orgID == "" {
+		orgID = c.Query("orgId")
+	}
+	if orgID == "" {
+		return utils.Fail(c, fiber.StatusBadRequest, "orgId is required")
+	}
+
+	token, err := h.authn.IssueAnonymousToken(orgID)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to issue token.%v", err))
+	}
+	return utils.Success(c, token)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type ConversationHandler interface {
+	GetSessionMessages(c *fiber.Ctx) error
+	ListUserSessions(c *fiber.Ctx) error
+}
+
+type conversationHandler struct {
+	rdb *redis.Client
+}
+
+func NewConversationHandler(rdb *redis.Client) ConversationHandler {
+	return &conversationHandler{rdb: rdb}
+}
+
+func (h *conversationHandler) GetSessionMessages(c *fiber.Ctx) error {
+	params := models.AiQueryParams{
+		SessionID: c.Params("sessionId"),
+		UserID:    c.Query("userId"),
+		OrgID:     c.Query("orgId"),
+	}
+	if params.UserID == "" || params.OrgID == "" {
+		return utils.Fail(c, fiber.StatusBadRequest, "userId and orgId are required")
+	}
+
+	offset := c.QueryInt("offset", 0)
+	limit := c.QueryInt("limit", 50)
+	if offset < 0 || limit <= 0 || limit > 200 {
+		return utils.Fail(c, fiber.StatusBadRequest, "offset must not be negative and limit must be between 1 and 200")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	turns, err := helpers.GetChatTurns(ctx, h.rdb, params, offset, limit)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to get messages.%v", err))
+	}
+	return utils.Success(c, turns)
+}
+
+func (h *conversationHandler) ListUserSessions(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	sessions, err := helpers.ListUserSessions(ctx, h.rdb, c.Params("userId"), c.Params("orgId"))
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to list sessions.%v", err))
+	}
+	return utils.Success(c, sessions)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/health"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type HealthHandler interface {
+	Liveness(c *fiber.Ctx) error
+	Readiness(c *fiber.Ctx) error
+}
+
+type healthHandler struct {
+	checker health.Checker
+}
+
+func NewHealthHandler(checker health.Checker) HealthHandler {
+	return &healthHandler{checker: checker}
+}
+
+// Liveness only reports that the process is serving requests.
+func (h *healthHandler) Liveness(c *fiber.Ctx) error {
+	return utils.Success(c, fiber.Map{"status": models.HealthUp})
+}
+
+// Readiness reports each dependency's status and latency, answering 503
+// when a required one is down.
+func (h *healthHandler) Readiness(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	report := h.checker.Ready(ctx)
+	if report.Status != models.HealthUp {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"success": false,
+			"error":   "service not ready",
+			"data":    report,
+		})
+	}
+	return utils.Success(c, report)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/audit"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type MerchHandler interface {
+	ListRules(c *fiber.Ctx) error
+	CreateRule(c *fiber.Ctx) error
+	UpdateRule(c *fiber.Ctx) error
+	DeleteRule(c *fiber.Ctx) error
+	ListAudit(c *fiber.Ctx) error
+}
+
+type merchHandler struct {
+	rdb   *redis.Client
+	audit audit.Recorder
+}
+
+func NewMerchHandler(rdb *redis.Client, recorder audit.Recorder) MerchHandler {
+	return &merchHandler{rdb: rdb, audit: recorder}
+}
+
+func (m *merchHandler) ListRules(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	rules, err := helpers.GetMerchRules(ctx, m.rdb, c.Params("orgId"))
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to list rules.%v", err))
+	}
+	return utils.Success(c, rules)
+}
+
+func (m *merchHandler) CreateRule(c *fiber.Ctx) error {
+	var rule models.MerchRule
+
+	if err := c.BodyParser(&rule); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("failed to parse body: %v", err))
+	}
+
+	if err := rule.Validate(); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	rule.ID = uuid.New().String()
+	rule.UpdatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	if err := helpers.SetMerchRule(ctx, m.rdb, c.Params("orgId"), rule); err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to save rule.%v", err))
+	}
+	m.audit.Record(c, models.AuditMerchRuleCreate, rule.ID, nil, rule)
+	return utils.Success(c, rule)
+}
+
+func (m *merchHandler) UpdateRule(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	orgID := c.Params("orgId")
+	ruleID := c.Params("ruleId")
+
+	existing, err := helpers.GetMerchRule(ctx, m.rdb, orgID, ruleID)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to get rule.%v", err))
+	}
+	if existing == nil {
+		return utils.Fail(c, fiber.StatusNotFound, "rule not found")
+	}
+
+	var rule models.MerchRule
+	if err := c.BodyParser(&rule); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("failed to parse body: %v", err))
+	}
+
+	if err := rule.Validate(); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	rule.ID = ruleID
+	rule.UpdatedAt = time.Now()
+
+	if err := helpers.SetMerchRule(ctx, m.rdb, orgID, rule); err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to save rule.%v", err))
+	}
+	m.audit.Record(c, models.AuditMerchRuleUpdate, ruleID, existing, rule)
+	return utils.Success(c, rule)
+}
+
+func (m *merchHandler) DeleteRule(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	found, err := helpers.DeleteMerchRule(ctx, m.rdb, c.Params("orgId"), c.Params("ruleId"))
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to delete rule.%v", err))
+	}
+	if !found {
+		return utils.Fail(c, fiber.StatusNotFound, "rule not found")
+	}
+	m.audit.Record(c, models.AuditMerchRuleDelete, c.Params("ruleId"), nil, nil)
+	return utils.Success(c, "Successfully deleted rule")
+}
+
+func (m *merchHandler) ListAudit(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 1000 {
+		return utils.Fail(c, fiber.StatusBadRequest, "limit must be between 1 and 1000")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	audits, err := helpers.GetMerchAudit(ctx, m.rdb, c.Params("orgId"), limit)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to get audit.%v", err))
+	}
+	return utils.Success(c, audits)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/auth"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type PreferencesHandler interface {
+	GetPreferences(c *fiber.Ctx) error
+	UpdatePreferences(c *fiber.Ctx) error
+	DeletePreferences(c *fiber.Ctx) error
+}
+
+type preferencesHandler struct {
+	rdb *redis.Client
+	log *slog.Logger
+}
+
+func NewPreferencesHandler(rdb *redis.Client, log *slog.Logger) PreferencesHandler {
+	return &preferencesHandler{rdb: rdb, log: log}
+}
+
+func (h *preferencesHandler) GetPreferences(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	prefs, err := helpers.GetUserPreferences(ctx, h.rdb, c.Params("userId"), c.Params("orgId"))
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to get preferences.%v", err))
+	}
+	return utils.Success(c, prefs)
+}
+
+// UpdatePreferences replaces the shopper's profile, letting them correct
+// anything the assistant inferred.
+func (h *preferencesHandler) UpdatePreferences(c *fiber.Ctx) error {
+	var prefs models.ShopperPreferences
+
+	if err := c.BodyParser(&prefs); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("failed to parse body: %v", err))
+	}
+
+	if err := prefs.Validate(); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	orgID := c.Params("orgId")
+
+	ctx, cancel := context.WithTimeout(auth.LogContext(c), time.Second*5)
+	defer cancel()
+
+	retention, err := helpers.GetRetentionPolicy(ctx, h.rdb, orgID)
+	if err != nil {
+		h.log.WarnContext(ctx, "failed to load retention policy, using defaults", "err", err)
+	}
+
+	if err := helpers.SetUserPreferences(ctx, h.rdb, c.Params("userId"), orgID, prefs, retention.HistoryTTL()); err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to save preferences.%v", err))
+	}
+
+	prefs, err = helpers.GetUserPreferences(ctx, h.rdb, c.Params("userId"), orgID)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to get preferences.%v", err))
+	}
+	return utils.Success(c, prefs)
+}
+
+func (h *preferencesHandler) DeletePreferences(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	if _, err := helpers.DeleteUserPreferences(ctx, h.rdb, c.Params("userId"), c.Params("orgId")); err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to delete preferences.%v", err))
+	}
+	return utils.Success(c, "preferences deleted")
+}
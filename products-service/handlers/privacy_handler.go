@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/audit"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type PrivacyHandler interface {
+	EraseUserData(c *fiber.Ctx) error
+	DeleteSession(c *fiber.Ctx) error
+	GetRetentionPolicy(c *fiber.Ctx) error
+	UpdateRetentionPolicy(c *fiber.Ctx) error
+}
+
+type privacyHandler struct {
+	rdb   *redis.Client
+	audit audit.Recorder
+}
+
+func NewPrivacyHandler(rdb *redis.Client, recorder audit.Recorder) PrivacyHandler {
+	return &privacyHandler{rdb: rdb, audit: recorder}
+}
+
+func (p *privacyHandler) EraseUserData(c *fiber.Ctx) error {
+	orgID := c.Params("orgId")
+	userID := c.Params("userId")
+
+	// Erasure may outlast the default request timeout.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.UserContext()), time.Second*30)
+	defer cancel()
+
+	deleted, auditRemoved, err := helpers.EraseUserData(ctx, p.rdb, orgID, userID)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to erase user data.%v", err))
+	}
+	p.audit.Record(c, models.AuditUserErase, models.UserPrincipal(userID), nil, fiber.Map{"deletedKeys": deleted})
+
+	return utils.Success(c, models.ErasureReceipt{
+		ReceiptID:           uuid.New().String(),
+		OrgID:               orgID,
+		UserID:              userID,
+		DeletedKeys:         deleted,
+		AuditEntriesRemoved: auditRemoved,
+		CompletedAt:         time.Now(),
+	})
+}
+
+func (p *privacyHandler) DeleteSession(c *fiber.Ctx) error {
+	params := models.AiQueryParams{
+		SessionID: c.Params("sessionId"),
+		UserID:    c.Query("userId"),
+		OrgID:     c.Query("orgId"),
+	}
+	if params.UserID == "" || params.OrgID == "" {
+		return utils.Fail(c, fiber.StatusBadRequest, "userId and orgId are required")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	deleted, err := helpers.DeleteSession(ctx, p.rdb, params)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to delete session.%v", err))
+	}
+
+	return utils.Success(c, models.ErasureReceipt{
+		ReceiptID:   uuid.New().String(),
+		OrgID:       params.OrgID,
+		UserID:      params.UserID,
+		SessionID:   params.SessionID,
+		DeletedKeys: deleted,
+		CompletedAt: time.Now(),
+	})
+}
+
+func (p *privacyHandler) GetRetentionPolicy(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	policy, err := helpers.GetRetentionPolicy(ctx, p.rdb, c.Params("orgId"))
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to get retention policy.%v", err))
+	}
+	return utils.Success(c, policy)
+}
+
+func (p *privacyHandler) UpdateRetentionPolicy(c *fiber.Ctx) error {
+	policy := models.DefaultRetentionPolicy()
+
+	if err := c.BodyParser(&policy); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("failed to parse body: %v", err))
+	}
+
+	if err := policy.Validate(); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	before, _ := helpers.GetRetentionPolicy(ctx, p.rdb, c.Params("orgId"))
+
+	if err := helpers.SetRetentionPolicy(ctx, p.rdb, c.Params("orgId"), policy); err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to save retention policy.%v", err))
+	}
+	p.audit.Record(c, models.AuditRetentionSet, "retention_policy", before, policy)
+	return utils.Success(c, policy)
+}
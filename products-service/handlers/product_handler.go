@@ -3,9 +3,15 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
+	"github.com/Adityadangi14/ecomm_ai/config"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/audit"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/auth"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/health"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/llm"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/mq"
@@ -18,14 +24,53 @@ import (
 type Handlers struct {
 	ProductHandlers ProductHandlers
 	QueryHandler    QueryHandler
+	SearchHandler   SearchHandler
+	SettingsHandler SearchSettingsHandler
+	MerchHandler    MerchHandler
+	ConvHandler     ConversationHandler
+	PrivacyHandler  PrivacyHandler
+	PrefsHandler    PreferencesHandler
+	UsageHandler    UsageHandler
+	QuotaHandler    QuotaHandler
+	APIKeyHandler   APIKeyHandler
+	AuthHandler     AuthHandler
+	RoleHandler     RoleHandler
+	AuditHandler    AuditHandler
+	HealthHandler   HealthHandler
 }
 
-func NewHandler(pub mq.ProductPublisher, productRepo repository.ProductRepository, aiCLient llm.Aiclient, rdb *redis.Client) *Handlers {
-	prodHandler := NewProductHandlers(pub, productRepo)
-	queryHandler := NewQueryHandler(aiCLient, rdb)
+func NewHandler(pub mq.ProductPublisher, productRepo repository.ProductRepository, aiCLient llm.Aiclient, rdb *redis.Client, cfg *config.Config, runtime *config.Runtime, authn auth.Authenticator, recorder audit.Recorder, checker health.Checker, log *slog.Logger) *Handlers {
+	prodHandler := NewProductHandlers(pub, productRepo, recorder, log)
+	queryHandler := NewQueryHandler(aiCLient, rdb, log)
+	searchHandler := NewSearchHandler(productRepo)
+	settingsHandler := NewSearchSettingsHandler(rdb, recorder, runtime)
+	merchHandler := NewMerchHandler(rdb, recorder)
+	convHandler := NewConversationHandler(rdb)
+	privacyHandler := NewPrivacyHandler(rdb, recorder)
+	prefsHandler := NewPreferencesHandler(rdb, log)
+	usageHandler := NewUsageHandler(rdb, cfg.LLM)
+	quotaHandler := NewQuotaHandler(rdb, runtime, recorder)
+	apiKeyHandler := NewAPIKeyHandler(rdb, recorder)
+	authHandler := NewAuthHandler(authn)
+	roleHandler := NewRoleHandler(rdb, recorder)
+	auditHandler := NewAuditHandler(rdb)
+	healthHandler := NewHealthHandler(checker)
 	return &Handlers{
 		ProductHandlers: prodHandler,
 		QueryHandler:    queryHandler,
+		SearchHandler:   searchHandler,
+		SettingsHandler: settingsHandler,
+		MerchHandler:    merchHandler,
+		ConvHandler:     convHandler,
+		PrivacyHandler:  privacyHandler,
+		PrefsHandler:    prefsHandler,
+		UsageHandler:    usageHandler,
+		QuotaHandler:    quotaHandler,
+		APIKeyHandler:   apiKeyHandler,
+		AuthHandler:     authHandler,
+		RoleHandler:     roleHandler,
+		AuditHandler:    auditHandler,
+		HealthHandler:   healthHandler,
 	}
 
 }
@@ -33,15 +78,19 @@ func NewHandler(pub mq.ProductPublisher, productRepo repository.ProductRepositor
 type ProductHandlers interface {
 	UploadProducts(c *fiber.Ctx) error
 	DeleteAllProducts(c *fiber.Ctx) error
+	UpdateStock(c *fiber.Ctx) error
+	PublishUpdates(c *fiber.Ctx) error
 }
 
 type prodHandlers struct {
 	prodPublisher mq.ProductPublisher
 	productRepo   repository.ProductRepository
+	audit         audit.Recorder
+	log           *slog.Logger
 }
 
-func NewProductHandlers(pub mq.ProductPublisher, productRepo repository.ProductRepository) ProductHandlers {
-	return &prodHandlers{prodPublisher: pub, productRepo: productRepo}
+func NewProductHandlers(pub mq.ProductPublisher, productRepo repository.ProductRepository, recorder audit.Recorder, log *slog.Logger) ProductHandlers {
+	return &prodHandlers{prodPublisher: pub, productRepo: productRepo, audit: recorder, log: log}
 }
 
 func (p *prodHandlers) UploadProducts(c *fiber.Ctx) error {
@@ -57,17 +106,21 @@ func (p *prodHandlers) UploadProducts(c *fiber.Ctx) error {
 		err       error
 	}
 
+	queued := make([]string, 0, len(products.Products))
 	for _, prod := range products.Products {
+		if orgID := auth.OrgID(c); orgID != "" {
+			prod.OrgID = orgID
+		}
 
 		byt, err := json.Marshal(prod)
 
 		if err != nil {
 			return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("failed to marshal body: %v", err))
 		}
-		err = p.prodPublisher.Publish(byt, "text")
+		err = p.prodPublisher.Publish(c.UserContext(), byt, "text")
 
 		if err != nil {
-			fmt.Println(err)
+			p.log.ErrorContext(auth.LogContext(c), "failed to queue product", "productId", prod.ID, "err", err)
 			errors = append(errors, struct {
 				ProductID string
 				err       error
@@ -75,8 +128,14 @@ func (p *prodHandlers) UploadProducts(c *fiber.Ctx) error {
 				ProductID: prod.ID,
 				err:       err,
 			})
+			continue
 		}
+		queued = append(queued, prod.ID)
+
+	}
 
+	if len(queued) != 0 {
+		p.audit.Record(c, models.AuditProductsUpload, "products", nil, fiber.Map{"productIds": queued})
 	}
 
 	if len(errors) != 0 {
@@ -89,13 +148,86 @@ func (p *prodHandlers) UploadProducts(c *fiber.Ctx) error {
 }
 
 func (p *prodHandlers) DeleteAllProducts(c *fiber.Ctx) error {
-	ctx, _ := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*10)
+	defer cancel()
 
 	err := p.productRepo.DeleteAllProducts(ctx)
 
 	if err != nil {
 		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to delete products.%v", err))
 	}
+	p.audit.Record(c, models.AuditProductsDeleteAll, "products", nil, nil)
 	return utils.Success(c, "Successfully deleted products")
 
 }
+
+func (p *prodHandlers) UpdateStock(c *fiber.Ctx) error {
+	var req models.StockUpdateRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("failed to parse body: %v", err))
+	}
+
+	if err := req.Validate(); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*10)
+	defer cancel()
+
+	err := p.productRepo.UpdateAvailability(ctx, c.Params("orgId"), c.Params("productId"), req.Skus)
+	if errors.Is(err, repository.ErrProductNotFound) {
+		return utils.Fail(c, fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to update stock.%v", err))
+	}
+	p.audit.Record(c, models.AuditProductsStock, c.Params("productId"), nil, req.Skus)
+	return utils.Success(c, "Successfully updated stock")
+}
+
+// PublishUpdates queues partial price and stock updates on the fast path.
+func (p *prodHandlers) PublishUpdates(c *fiber.Ctx) error {
+	var req models.ProductUpdatesRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("failed to parse body: %v", err))
+	}
+
+	if len(req.Updates) == 0 {
+		return utils.Fail(c, fiber.StatusBadRequest, "updates must not be empty")
+	}
+
+	orgID := c.Params("orgId")
+	for i := range req.Updates {
+		req.Updates[i].OrgID = orgID
+		if err := req.Updates[i].Validate(); err != nil {
+			return utils.Fail(c, fiber.StatusBadRequest, err.Error())
+		}
+	}
+
+	var failed []string
+	queued := make([]string, 0, len(req.Updates))
+	for _, update := range req.Updates {
+		byt, err := json.Marshal(update)
+		if err != nil {
+			return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("failed to marshal body: %v", err))
+		}
+		if err := p.prodPublisher.PublishUpdate(c.UserContext(), byt); err != nil {
+			p.log.ErrorContext(auth.LogContext(c), "failed to queue product update", "productId", update.ProductID, "err", err)
+			failed = append(failed, update.ProductID)
+			continue
+		}
+		queued = append(queued, update.ProductID)
+	}
+
+	if len(queued) != 0 {
+		p.audit.Record(c, models.AuditProductsUpdate, "products", nil, fiber.Map{"productIds": queued})
+	}
+
+	if len(failed) != 0 {
+		return utils.Fail(c, fiber.StatusMultiStatus, fmt.Sprintf("update failed for following items %v", failed))
+	}
+
+	return utils.Success(c, "Product updates have been queued successfully")
+}
@@ -5,9 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/auth"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/llm"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/metrics"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
 	"github.com/Adityadangi14/ecomm_ai/utils"
 	"github.com/gofiber/fiber/v2"
@@ -22,12 +26,14 @@ type QueryHandler interface {
 type queryHandler struct {
 	aiClient llm.Aiclient
 	rdb      *redis.Client
+	log      *slog.Logger
 }
 
-func NewQueryHandler(aiClient llm.Aiclient, rdb *redis.Client) QueryHandler {
+func NewQueryHandler(aiClient llm.Aiclient, rdb *redis.Client, log *slog.Logger) QueryHandler {
 	return &queryHandler{
 		aiClient: aiClient,
 		rdb:      rdb,
+		log:      log,
 	}
 }
 
@@ -44,13 +50,32 @@ func (q *queryHandler) GetAiResponse(c *fiber.Ctx) error {
 		return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("failed to parse body: %v", err))
 	}
 
+	query.RequestID, _ = c.Locals("requestid").(string)
+	if orgID := auth.OrgID(c); orgID != "" {
+		query.OrgID = orgID
+	}
+	if userID := auth.UserID(c); userID != "" {
+		query.UserID = userID
+	}
+	if sessionID := auth.SessionID(c); sessionID != "" {
+		query.SessionID = sessionID
+	}
+
+	// The stream outlives c, so its contexts are taken up front and detached
+	// from the request's deadline. The answer is cancelled instead when the
+	// client goes away.
+	streamCtx, cancelStream := context.WithCancel(context.WithoutCancel(c.UserContext()))
+	logCtx := context.WithoutCancel(auth.LogContext(c))
+
 	msgChan := make(chan models.MessageChanStruct)
 
-	go q.aiClient.GetAiQueryReponse(models.AiQueryParams{Query: query.Query, SessionID: query.SessionID, UserID: query.UserID, OrgID: query.OrgID}, msgChan)
+	go q.aiClient.GetAiQueryReponse(streamCtx, models.AiQueryParams{Query: query.Query, SessionID: query.SessionID, UserID: query.UserID, OrgID: query.OrgID, ProductID: query.ProductID, RequestID: query.RequestID}, msgChan)
 
 	response := ""
+	turn := models.ChatTurn{RequestID: query.RequestID, Query: query.Query, StartedAt: time.Now()}
 
 	c.Status(fiber.StatusOK).Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer cancelStream()
 		for {
 			select {
 			case msg, ok := <-msgChan:
@@ -58,28 +83,54 @@ func (q *queryHandler) GetAiResponse(c *fiber.Ctx) error {
 					// Channel closed, end stream
 					fmt.Fprintf(w, "data: [DONE]\n\n")
 					w.Flush()
-					err := SummerizePastChats(q.rdb, q.aiClient, response, query)
+					observeStream(turn)
+					q.recordTurn(logCtx, query, turn, response)
+					err := SummerizePastChats(logCtx, q.log, q.rdb, q.aiClient, response, query)
 					if err != nil {
-						fmt.Println("error summarizing chat ,", err)
+						q.log.ErrorContext(logCtx, "error summarizing chat", "err", err)
 					}
 					return
 				}
 
+				if msg.Meta != nil {
+					turn.Model = msg.Meta.Model
+					turn.ProductIDs = msg.Meta.ProductIDs
+					continue
+				}
+
 				if msg.Err != nil {
 					// Send error as SSE
 					fmt.Fprintf(w, "data: {\"error\": \"%s\"}\n\n", msg.Err.Error())
 
 					w.Flush()
+					observeStream(turn)
+					turn.Error = msg.Err.Error()
+					q.recordTurn(logCtx, query, turn, response)
 					return
 				}
 
+				if turn.FirstTokenMs == 0 {
+					firstToken := time.Since(turn.StartedAt)
+					turn.FirstTokenMs = firstToken.Milliseconds()
+					metrics.StreamTimeToFirstToken.WithLabelValues(turn.Model).Observe(firstToken.Seconds())
+				}
+
 				// Send chunk as SSE
 				fmt.Fprintf(w, "data: %s\n\n", msg.Chunk)
 				response = response + msg.Chunk
 				err := w.Flush()
 				if err != nil {
 					// Connection closed by client
-					fmt.Printf("Error flushing: %v. Closing connection.\n", err)
+					q.log.InfoContext(logCtx, "client closed stream", "err", err)
+					observeStream(turn)
+
+					// Stop generating and wait for the answer to finish so its
+					// usage is recorded, then keep what the shopper saw.
+					cancelStream()
+					for range msgChan {
+					}
+					turn.Interrupted = true
+					q.recordTurn(logCtx, query, turn, response)
 					return
 				}
 			}
@@ -90,14 +141,43 @@ func (q *queryHandler) GetAiResponse(c *fiber.Ctx) error {
 
 }
 
-func SummerizePastChats(rdb *redis.Client, aiClient llm.Aiclient, response string, parms models.AiQueryParams) error {
+// observeStream records the duration of a finished answer stream.
+func observeStream(turn models.ChatTurn) {
+	metrics.StreamDuration.WithLabelValues(turn.Model).Observe(time.Since(turn.StartedAt).Seconds())
+}
+
+// recordTurn appends the finished turn to the session history.
+func (q *queryHandler) recordTurn(ctx context.Context, params models.AiQueryParams, turn models.ChatTurn, response string) {
+	turn.Response = response
+	turn.TotalMs = time.Since(turn.StartedAt).Milliseconds()
+
+	retention, err := helpers.GetRetentionPolicy(ctx, q.rdb, params.OrgID)
+	if err != nil {
+		q.log.WarnContext(ctx, "failed to load retention policy, using defaults", "err", err)
+	}
+
+	if err := helpers.AppendChatTurn(ctx, q.rdb, params, turn, retention.HistoryTTL()); err != nil {
+		q.log.ErrorContext(ctx, "error storing chat turn", "err", err)
+	}
+}
+
+func SummerizePastChats(ctx context.Context, log *slog.Logger, rdb *redis.Client, aiClient llm.Aiclient, response string, parms models.AiQueryParams) error {
 	key := helpers.GetUserChatKey(parms)
-	res, err := helpers.GetUserChat(context.Background(), rdb, key)
+
+	retention, err := helpers.GetRetentionPolicy(ctx, rdb, parms.OrgID)
 	if err != nil {
-		fmt.Println("error getting user chat")
+		log.WarnContext(ctx, "failed to load retention policy, using defaults", "err", err)
+	}
+
+	if err := helpers.TrackUserSession(ctx, rdb, parms, retention); err != nil {
 		return err
 	}
 
+	res, err := helpers.GetUserChat(ctx, rdb, key)
+	if err != nil {
+		return fmt.Errorf("unable to get user chat.%v", err)
+	}
+
 	chat := map[string]string{
 		"query":       parms.Query,
 		"ai-response": response,
@@ -106,16 +186,12 @@ func SummerizePastChats(rdb *redis.Client, aiClient llm.Aiclient, response strin
 	str, err := json.Marshal(chat)
 
 	if res == "" {
-		err := helpers.SetUserChat(rdb, key, string(str), context.Background())
-		fmt.Println("Error setting user chat ", err)
-		return nil
+		return helpers.SetUserChat(rdb, key, string(str), retention.ChatTTL(), ctx)
 	}
 
-	fmt.Println("summerziation inputs", res, string(str))
-	summary := aiClient.SummerizePastChats(res, string(str))
+	summary := aiClient.SummerizePastChats(llm.WithUsageScope(ctx, parms.OrgID, parms.UserID), res, string(str))
 
-	err = helpers.SetUserChat(rdb, key, summary, context.Background())
-	fmt.Println("summerize chats", res)
+	err = helpers.SetUserChat(rdb, key, summary, retention.ChatTTL(), ctx)
 	if err != nil {
 		return err
 	}
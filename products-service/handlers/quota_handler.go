@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/config"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/audit"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type QuotaHandler interface {
+	GetQuota(c *fiber.Ctx) error
+	UpdatePlan(c *fiber.Ctx) error
+}
+
+type quotaHandler struct {
+	rdb     *redis.Client
+	runtime *config.Runtime
+	audit   audit.Recorder
+}
+
+func NewQuotaHandler(rdb *redis.Client, runtime *config.Runtime, recorder audit.Recorder) QuotaHandler {
+	return &quotaHandler{rdb: rdb, runtime: runtime, audit: recorder}
+}
+
+func (h *quotaHandler) GetQuota(c *fiber.Ctx) error {
+	orgID := c.Params("orgId")
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	name, err := helpers.GetOrgPlan(ctx, h.rdb, orgID)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to get plan.%v", err))
+	}
+	plan := h.runtime.Current().Quotas.Plan(name)
+
+	uploads, err := helpers.GetDailyUploads(ctx, h.rdb, orgID)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to get uploads.%v", err))
+	}
+
+	tokens, err := helpers.GetMonthlyTokens(ctx, h.rdb, orgID)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to get token usage.%v", err))
+	}
+
+	return utils.Success(c, models.QuotaStatus{
+		Plan:                 plan.Name,
+		ChatPerMinute:        plan.ChatPerMinute,
+		ChatPerMinutePerUser: plan.ChatPerMinutePerUser,
+		UploadsPerDay:        plan.UploadsPerDay,
+		UploadsToday:         uploads,
+		MonthlyTokens:        plan.MonthlyTokens,
+		TokensThisMonth:      tokens,
+	})
+}
+
+func (h *quotaHandler) UpdatePlan(c *fiber.Ctx) error {
+	var req struct {
+		Plan string `json:"plan"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("failed to parse body: %v", err))
+	}
+
+	if !h.runtime.Current().Quotas.HasPlan(req.Plan) {
+		return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("unknown plan %q", req.Plan))
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	before, _ := helpers.GetOrgPlan(ctx, h.rdb, c.Params("orgId"))
+
+	if err := helpers.SetOrgPlan(ctx, h.rdb, c.Params("orgId"), req.Plan); err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to save plan.%v", err))
+	}
+	h.audit.Record(c, models.AuditPlanSet, "plan", before, req.Plan)
+	return h.GetQuota(c)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/audit"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/auth"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type RoleHandler interface {
+	ListRoles(c *fiber.Ctx) error
+	AssignRole(c *fiber.Ctx) error
+	RemoveRole(c *fiber.Ctx) error
+}
+
+type roleHandler struct {
+	rdb   *redis.Client
+	audit audit.Recorder
+}
+
+func NewRoleHandler(rdb *redis.Client, recorder audit.Recorder) RoleHandler {
+	return &roleHandler{rdb: rdb, audit: recorder}
+}
+
+func (h *roleHandler) ListRoles(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	assignments, err := helpers.ListRoleAssignments(ctx, h.rdb, c.Params("orgId"))
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to list roles.%v", err))
+	}
+	return utils.Success(c, assignments)
+}
+
+func (h *roleHandler) AssignRole(c *fiber.Ctx) error {
+	var req struct {
+		Role string `json:"role"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("failed to parse body: %v", err))
+	}
+
+	orgID := c.Params("orgId")
+	principal := c.Params("principal")
+
+	if err := models.ValidateRoleAssignment(principal, req.Role); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if principal == auth.Principal(c) {
+		return utils.Fail(c, fiber.StatusForbidden, "you cannot change your own role")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	current, err := helpers.GetRoleAssignment(ctx, h.rdb, orgID, principal)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to get role.%v", err))
+	}
+
+	if (req.Role == models.RoleOwner || (current != nil && current.Role == models.RoleOwner)) && !canManageOwners(c) {
+		return utils.Fail(c, fiber.StatusForbidden, "only owners can grant or change the owner role")
+	}
+
+	assignment := models.RoleAssignment{
+		Principal:  principal,
+		Role:       req.Role,
+		AssignedBy: auth.Principal(c),
+		AssignedAt: time.Now(),
+	}
+
+	if err := helpers.SetRoleAssignment(ctx, h.rdb, orgID, assignment); err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to assign role.%v", err))
+	}
+	h.audit.Record(c, models.AuditRoleAssign, principal, current, assignment)
+	return utils.Success(c, assignment)
+}
+
+func (h *roleHandler) RemoveRole(c *fiber.Ctx) error {
+	orgID := c.Params("orgId")
+	principal := c.Params("principal")
+
+	if principal == auth.Principal(c) {
+		return utils.Fail(c, fiber.StatusForbidden, "you cannot change your own role")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	current, err := helpers.GetRoleAssignment(ctx, h.rdb, orgID, principal)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to get role.%v", err))
+	}
+	if current == nil {
+		return utils.Fail(c, fiber.StatusNotFound, "role assignment not found")
+	}
+
+	if current.Role == models.RoleOwner && !canManageOwners(c) {
+		return utils.Fail(c, fiber.StatusForbidden, "only owners can remove the owner role")
+	}
+
+	if _, err := helpers.DeleteRoleAssignment(ctx, h.rdb, orgID, principal); err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to remove role.%v", err))
+	}
+	h.audit.Record(c, models.AuditRoleRemove, principal, current, nil)
+	return utils.Success(c, "role assignment removed")
+}
+
+func canManageOwners(c *fiber.Ctx) bool {
+	role := auth.Role(c)
+	return role == models.RoleOwner || role == models.RoleSuperAdmin
+}
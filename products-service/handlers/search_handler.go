@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/repository"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+const maxSearchLimit = 100
+
+type SearchHandler interface {
+	SearchProducts(c *fiber.Ctx) error
+	SimilarProducts(c *fiber.Ctx) error
+}
+
+type searchHandler struct {
+	productRepo repository.ProductRepository
+}
+
+func NewSearchHandler(productRepo repository.ProductRepository) SearchHandler {
+	return &searchHandler{productRepo: productRepo}
+}
+
+func (s *searchHandler) SearchProducts(c *fiber.Ctx) error {
+	params, err := parseSearchParams(c)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*10)
+	defer cancel()
+
+	res, err := s.productRepo.SearchProducts(ctx, params)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to search products.%v", err))
+	}
+
+	return utils.Success(c, res)
+}
+
+func (s *searchHandler) SimilarProducts(c *fiber.Ctx) error {
+	params, err := parseSearchParams(c)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, err.Error())
+	}
+	params.Query = ""
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*10)
+	defer cancel()
+
+	res, err := s.productRepo.SimilarProducts(ctx, models.SimilarParams{
+		SearchParams: params,
+		ProductID:    c.Params("productId"),
+	})
+	if errors.Is(err, repository.ErrProductNotFound) {
+		return utils.Fail(c, fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to get similar products.%v", err))
+	}
+
+	return utils.Success(c, res)
+}
+
+func parseSearchParams(c *fiber.Ctx) (models.SearchParams, error) {
+	params := models.SearchParams{
+		OrgID: c.Params("orgId"),
+		Query: c.Query("q"),
+		Brand: c.Query("brand"),
+
+		InStockOnly: c.QueryBool("inStock", false),
+	}
+
+	if params.OrgID == "" {
+		return params, fmt.Errorf("orgId is required")
+	}
+
+	// Without a limit the org's search settings decide the page size.
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			return params, fmt.Errorf("limit must be between 1 and %d", maxSearchLimit)
+		}
+		params.Limit = limit
+	}
+
+	offset, err := repository.DecodeCursor(c.Query("cursor"))
+	if err != nil {
+		return params, err
+	}
+	params.Offset = offset
+
+	if params.MinPrice, err = parsePriceQuery(c, "minPrice"); err != nil {
+		return params, err
+	}
+	if params.MaxPrice, err = parsePriceQuery(c, "maxPrice"); err != nil {
+		return params, err
+	}
+	if params.MinPrice != nil && params.MaxPrice != nil && *params.MinPrice > *params.MaxPrice {
+		return params, fmt.Errorf("minPrice must not exceed maxPrice")
+	}
+
+	return params, nil
+}
+
+func parsePriceQuery(c *fiber.Ctx, key string) (*float64, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	price, err := strconv.ParseFloat(raw, 64)
+	if err != nil || price < 0 {
+		return nil, fmt.Errorf("invalid %s: %q", key, raw)
+	}
+	return &price, nil
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+// parse runs parseSearchParams on a request for the given query string.
+func parse(t *testing.T, query url.Values) (models.SearchParams, error) {
+	t.Helper()
+
+	var params models.SearchParams
+	var parseErr error
+
+	app := fiber.New()
+	app.Get("/orgs/:orgId/search", func(c *fiber.Ctx) error {
+		params, parseErr = parseSearchParams(c)
+		return nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/orgs/org1/search?"+query.Encode(), nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	resp.Body.Close()
+	return params, parseErr
+}
+
+func TestParseSearchParams(t *testing.T) {
+	params, err := parse(t, url.Values{
+		"q":        {"red shoes"},
+		"brand":    {"acme"},
+		"limit":    {"25"},
+		"inStock":  {"true"},
+		"minPrice": {"10"},
+		"maxPrice": {"99.5"},
+		"cursor":   {repository.EncodeCursor(50)},
+	})
+	if err != nil {
+		t.Fatalf("parseSearchParams: %v", err)
+	}
+
+	if params.OrgID != "org1" || params.Query != "red shoes" || params.Brand != "acme" {
+		t.Errorf("got org %q, query %q, brand %q", params.OrgID, params.Query, params.Brand)
+	}
+	if params.Limit != 25 || params.Offset != 50 || !params.InStockOnly {
+		t.Errorf("got limit %d, offset %d, inStock %v", params.Limit, params.Offset, params.InStockOnly)
+	}
+	if params.MinPrice == nil || *params.MinPrice != 10 || params.MaxPrice == nil || *params.MaxPrice != 99.5 {
+		t.Errorf("got prices %v and %v, want 10 and 99.5", params.MinPrice, params.MaxPrice)
+	}
+}
+
+func TestParseSearchParamsDefaults(t *testing.T) {
+	params, err := parse(t, url.Values{})
+	if err != nil {
+		t.Fatalf("parseSearchParams: %v", err)
+	}
+
+	// A zero limit leaves the page size to the org's search settings.
+	if params.Limit != 0 || params.Offset != 0 || params.InStockOnly {
+		t.Errorf("got limit %d, offset %d, inStock %v", params.Limit, params.Offset, params.InStockOnly)
+	}
+	if params.MinPrice != nil || params.MaxPrice != nil {
+		t.Errorf("got prices %v and %v, want none", params.MinPrice, params.MaxPrice)
+	}
+}
+
+func TestParseSearchParamsErrors(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	tests := []struct {
+		name  string
+		query url.Values
+		want  string
+	}{
+		{name: "zero limit", query: url.Values{"limit": {"0"}}, want: "limit must be between 1 and 100"},
+		{name: "negative limit", query: url.Values{"limit": {"-1"}}, want: "limit must be between 1 and 100"},
+		{name: "limit too large", query: url.Values{"limit": {"101"}}, want: "limit must be between 1 and 100"},
+		{name: "limit not a number", query: url.Values{"limit": {"ten"}}, want: "limit must be between 1 and 100"},
+		{name: "cursor not base64", query: url.Values{"cursor": {"%%%"}}, want: "invalid cursor"},
+		{name: "cursor not a number", query: url.Values{"cursor": {encode("ten")}}, want: "invalid cursor"},
+		{name: "negative cursor", query: url.Values{"cursor": {encode("-10")}}, want: "invalid cursor"},
+		{name: "price not a number", query: url.Values{"minPrice": {"cheap"}}, want: `invalid minPrice: "cheap"`},
+		{name: "negative price", query: url.Values{"maxPrice": {"-5"}}, want: `invalid maxPrice: "-5"`},
+		{name: "inverted price range", query: url.Values{"minPrice": {"50"}, "maxPrice": {"10"}}, want: "minPrice must not exceed maxPrice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse(t, tt.query)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, offset := range []int{0, 1, 20, 12345} {
+		got, err := repository.DecodeCursor(repository.EncodeCursor(offset))
+		if err != nil || got != offset {
+			t.Errorf("DecodeCursor(EncodeCursor(%d)) = %d, %v", offset, got, err)
+		}
+	}
+}
+
+// searchRepo answers SearchProducts with a fixed result.
+type searchRepo struct {
+	repository.ProductRepository
+	result *models.SearchResult
+}
+
+func (r searchRepo) SearchProducts(ctx context.Context, params models.SearchParams) (*models.SearchResult, error) {
+	return r.result, nil
+}
+
+func TestSearchProductsReportsApproximateFacets(t *testing.T) {
+	brands := []models.FacetBucket{{Value: "nike", Count: 200}}
+
+	tests := []struct {
+		name        string
+		approximate bool
+	}{
+		{name: "exact"},
+		{name: "approximate", approximate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewSearchHandler(searchRepo{result: &models.SearchResult{
+				Products:          []models.ProductHit{},
+				Facets:            map[string][]models.FacetBucket{"brand": brands},
+				FacetsApproximate: tt.approximate,
+			}})
+
+			app := fiber.New()
+			app.Get("/orgs/:orgId/search", handler.SearchProducts)
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/orgs/org1/search?q=shoes", nil))
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			var body struct {
+				Data struct {
+					Facets            map[string][]models.FacetBucket `json:"facets"`
+					FacetsApproximate *bool                           `json:"facetsApproximate"`
+				} `json:"data"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+
+			if !slices.Equal(body.Data.Facets["brand"], brands) {
+				t.Errorf("brand facets = %v, want %v", body.Data.Facets["brand"], brands)
+			}
+			if tt.approximate && (body.Data.FacetsApproximate == nil || !*body.Data.FacetsApproximate) {
+				t.Error("approximate facets are not flagged")
+			}
+			if !tt.approximate && body.Data.FacetsApproximate != nil {
+				t.Error("exact facets carry facetsApproximate")
+			}
+		})
+	}
+}
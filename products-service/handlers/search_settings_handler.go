@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/config"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/audit"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type SearchSettingsHandler interface {
+	GetSearchSettings(c *fiber.Ctx) error
+	UpdateSearchSettings(c *fiber.Ctx) error
+	ResetSearchSettings(c *fiber.Ctx) error
+}
+
+type searchSettingsHandler struct {
+	rdb     *redis.Client
+	audit   audit.Recorder
+	runtime *config.Runtime
+}
+
+func NewSearchSettingsHandler(rdb *redis.Client, recorder audit.Recorder, runtime *config.Runtime) SearchSettingsHandler {
+	return &searchSettingsHandler{rdb: rdb, audit: recorder, runtime: runtime}
+}
+
+func (s *searchSettingsHandler) defaults() models.SearchSettings {
+	return models.DefaultSearchSettings(s.runtime.Current().Search)
+}
+
+func (s *searchSettingsHandler) GetSearchSettings(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	settings, err := helpers.GetSearchSettings(ctx, s.rdb, c.Params("orgId"), s.defaults())
+	if err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to get search settings.%v", err))
+	}
+	return utils.Success(c, settings)
+}
+
+func (s *searchSettingsHandler) UpdateSearchSettings(c *fiber.Ctx) error {
+	settings := s.defaults()
+
+	if err := c.BodyParser(&settings); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("failed to parse body: %v", err))
+	}
+
+	if err := settings.Validate(); err != nil {
+		return utils.Fail(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	before, _ := helpers.GetSearchSettings(ctx, s.rdb, c.Params("orgId"), s.defaults())
+
+	if err := helpers.SetSearchSettings(ctx, s.rdb, c.Params("orgId"), settings); err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to save search settings.%v", err))
+	}
+	s.audit.Record(c, models.AuditSearchSettingsSet, "search_settings", before, settings)
+	return utils.Success(c, settings)
+}
+
+func (s *searchSettingsHandler) ResetSearchSettings(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+	defer cancel()
+
+	defaults := s.defaults()
+	before, _ := helpers.GetSearchSettings(ctx, s.rdb, c.Params("orgId"), defaults)
+
+	if err := helpers.DeleteSearchSettings(ctx, s.rdb, c.Params("orgId")); err != nil {
+		return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to reset search settings.%v", err))
+	}
+	s.audit.Record(c, models.AuditSearchSettingsReset, "search_settings", before, defaults)
+	return utils.Success(c, defaults)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/config"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// maxUsageDays bounds how many days a single usage report may span.
+const maxUsageDays = 366
+
+type UsageHandler interface {
+	GetUsage(c *fiber.Ctx) error
+}
+
+type usageHandler struct {
+	rdb *redis.Client
+	cfg config.LLMConfig
+}
+
+func NewUsageHandler(rdb *redis.Client, cfg config.LLMConfig) UsageHandler {
+	return &usageHandler{rdb: rdb, cfg: cfg}
+}
+
+// GetUsage reports the org's token usage and estimated cost per day between
+// the from and to dates (YYYY-MM-DD, UTC, inclusive). It defaults to the
+// last 30 days.
+func (h *usageHandler) GetUsage(c *fiber.Ctx) error {
+	orgID := c.Params("orgId")
+
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -29)
+
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			return utils.Fail(c, fiber.StatusBadRequest, "from must be a YYYY-MM-DD date")
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			return utils.Fail(c, fiber.StatusBadRequest, "to must be a YYYY-MM-DD date")
+		}
+	}
+
+	from = from.Truncate(24 * time.Hour)
+	to = to.Truncate(24 * time.Hour)
+	if to.Before(from) {
+		return utils.Fail(c, fiber.StatusBadRequest, "from must not be after to")
+	}
+	if to.Sub(from) >= maxUsageDays*24*time.Hour {
+		return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("a report may span at most %d days", maxUsageDays))
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*10)
+	defer cancel()
+
+	report := models.UsageReport{
+		OrgID:    orgID,
+		From:     from.Format("2006-01-02"),
+		To:       to.Format("2006-01-02"),
+		Currency: h.cfg.PriceCurrency,
+		Days:     []models.DailyUsage{},
+	}
+
+	users := map[string]*models.UsageLine{}
+	var userOrder []string
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+
+		lines, userLines, err := helpers.GetDailyUsage(ctx, h.rdb, orgID, date)
+		if err != nil {
+			return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to get usage.%v", err))
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		daily := models.DailyUsage{Date: date, Lines: lines}
+		for i := range daily.Lines {
+			h.price(&daily.Lines[i])
+			addUsage(&daily.Total, daily.Lines[i])
+		}
+		addUsage(&report.Total, daily.Total)
+		report.Days = append(report.Days, daily)
+
+		for _, l := range userLines {
+			h.price(&l)
+			u, ok := users[l.UserID]
+			if !ok {
+				u = &models.UsageLine{UserID: l.UserID}
+				users[l.UserID] = u
+				userOrder = append(userOrder, l.UserID)
+			}
+			addUsage(u, l)
+		}
+	}
+
+	report.ByUser = make([]models.UsageLine, 0, len(userOrder))
+	for _, id := range userOrder {
+		report.ByUser = append(report.ByUser, *users[id])
+	}
+
+	return utils.Success(c, report)
+}
+
+func (h *usageHandler) price(line *models.UsageLine) {
+	line.EstimatedCost = h.cfg.EstimateCost(line.Model, line.PromptTokens, line.CompletionTokens)
+}
+
+func addUsage(total *models.UsageLine, line models.UsageLine) {
+	total.Requests += line.Requests
+	total.PromptTokens += line.PromptTokens
+	total.CompletionTokens += line.CompletionTokens
+	total.EstimatedCost += line.EstimatedCost
+}
@@ -1,20 +1,28 @@
 package main
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "net/http/pprof"
 
 	"github.com/Adityadangi14/ecomm_ai/config"
 	"github.com/Adityadangi14/ecomm_ai/pkg/WDB"
+	"github.com/Adityadangi14/ecomm_ai/pkg/logger"
 	"github.com/Adityadangi14/ecomm_ai/pkg/rabbitmq"
+	"github.com/Adityadangi14/ecomm_ai/pkg/tracing"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/server"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+const serviceName = "products-service"
+
 func LoadEnvVariables() error {
 	err := godotenv.Load(".env")
 	if err != nil {
@@ -23,40 +31,84 @@ func LoadEnvVariables() error {
 	return nil
 }
 
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
 func main() {
 	err := LoadEnvVariables()
 	if err != nil {
-		log.Fatal(err)
+		fatal("loading env", err)
 	}
 
 	cfg, err := config.GetConfig(os.Getenv("config"))
 	if err != nil {
-		log.Fatal(err)
+		fatal("loading config", err)
+	}
+	if err := cfg.ValidateProducts(); err != nil {
+		fatal("loading config", err)
 	}
+
+	// Log locally until RabbitMQ is up, then ship to the log service too.
+	log := logger.New(cfg.Server, serviceName, nil)
+	slog.SetDefault(log)
+
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing, serviceName)
 	if err != nil {
-		log.Fatalf("Loading config: %v", err)
+		fatal("setting up tracing", err)
 	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error("failed to flush traces", "err", err)
+		}
+	}()
 
 	amqpConn, err := rabbitmq.NewRabbitMQConn(cfg)
 	if err != nil {
-		log.Fatal(err)
+		fatal("connecting to rabbitmq", err)
 	}
 	defer amqpConn.Close()
 
+	shipper, err := logger.NewShipper(amqpConn, cfg.RabbitMQ)
+	if err != nil {
+		log.Error("failed to set up log shipping, logging locally only", "err", err)
+	} else {
+		defer shipper.Close()
+		log = logger.New(cfg.Server, serviceName, shipper)
+		slog.SetDefault(log)
+	}
+
 	wdb, err := WDB.NewWeaviateDB(cfg)
 	if err != nil {
-		log.Fatal(err)
+		fatal("connecting to weaviate", err)
 	}
+	// Prometheus scrapes /metrics on the internal pprof port rather than the
+	// public API.
+	http.Handle("/metrics", promhttp.Handler())
+
 	go func() {
-		fmt.Println("Starting pprof server on :6060")
-		http.ListenAndServe(":6060", nil)
+		log.Info("starting pprof server", "addr", cfg.Server.PprofPort)
+		if err := http.ListenAndServe(cfg.Server.PprofPort, nil); err != nil {
+			log.Error("pprof server stopped", "err", err)
+		}
 	}()
 
-	s := server.NewProductServer(wdb, amqpConn, cfg)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	runtime := config.NewRuntime(cfg.Runtime, log)
+	if err := runtime.Watch(os.Getenv("config")); err != nil {
+		log.Error("failed to watch config, runtime settings will not reload", "err", err)
+	}
+
+	s := server.NewProductServer(wdb, amqpConn, cfg, runtime, log)
 
-	err = s.Run()
+	err = s.Run(ctx)
 
 	if err != nil {
-		log.Fatal(err)
+		fatal("server stopped", err)
 	}
 }
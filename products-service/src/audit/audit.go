@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/auth"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Publisher sends encoded audit events to the audit exchange.
+type Publisher interface {
+	PublishAudit(ctx context.Context, body []byte) error
+}
+
+// Recorder records audit events for mutating requests.
+type Recorder interface {
+	// Record emits an event for the request in c. before and after are
+	// optional snapshots of the changed object.
+	Record(c *fiber.Ctx, action string, target string, before any, after any)
+}
+
+type recorder struct {
+	pub Publisher
+	log *slog.Logger
+}
+
+func NewRecorder(pub Publisher, log *slog.Logger) Recorder {
+	return &recorder{pub: pub, log: log}
+}
+
+// Record never fails the request: the change has already been made, so a
+// publish failure is logged with the full event instead.
+func (r *recorder) Record(c *fiber.Ctx, action string, target string, before any, after any) {
+	orgID := c.Params("orgId")
+	if orgID == "" {
+		orgID = auth.OrgID(c)
+	}
+	if orgID == "" {
+		orgID = models.PlatformAuditOrg
+	}
+
+	requestID, _ := c.Locals("requestid").(string)
+
+	event := models.AuditEvent{
+		ID:        uuid.New().String(),
+		Time:      time.Now(),
+		OrgID:     orgID,
+		Actor:     auth.Principal(c),
+		Role:      auth.Role(c),
+		Action:    action,
+		Target:    target,
+		RequestID: requestID,
+		Before:    before,
+		After:     after,
+	}
+
+	byt, err := json.Marshal(event)
+	if err != nil {
+		r.log.ErrorContext(auth.LogContext(c), "failed to encode audit event", "action", action, "err", err)
+		return
+	}
+
+	if err := r.pub.PublishAudit(c.UserContext(), byt); err != nil {
+		r.log.ErrorContext(auth.LogContext(c), "failed to publish audit event", "event", string(byt), "err", err)
+	}
+}
@@ -0,0 +1,289 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/config"
+	"github.com/Adityadangi14/ecomm_ai/pkg/logger"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	localsKey      = "apiKey"
+	localsPlatform = "platform"
+	localsShopper  = "shopper"
+	localsToken    = "token"
+	localsRole     = "role"
+)
+
+// Authenticator verifies org API keys and shopper tokens on incoming
+// requests.
+type Authenticator interface {
+	// RequirePermission admits requests whose key or token holds a role with
+	// perm in the org named by any orgId in the path or query. API keys also
+	// need the scope the permission belongs to.
+	RequirePermission(perm string) fiber.Handler
+	IssueAnonymousToken(orgID string) (models.ShopperToken, error)
+}
+
+type authenticator struct {
+	rdb *redis.Client
+	cfg config.AuthConfig
+	jwt *jwtVerifier
+}
+
+func NewAuthenticator(rdb *redis.Client, cfg config.AuthConfig, jwtSecret string) (Authenticator, error) {
+	if cfg.PlatformKey == "" {
+		slog.Warn("no platform key configured, platform operations are disabled")
+	}
+	if cfg.AnonymousTokenMinutes <= 0 {
+		cfg.AnonymousTokenMinutes = 30
+	}
+
+	verifier, err := newJWTVerifier(jwtSecret, cfg.JwksFile, cfg.JwtIssuer)
+	if err != nil {
+		return nil, err
+	}
+	return &authenticator{rdb: rdb, cfg: cfg, jwt: verifier}, nil
+}
+
+// LogContext returns a context whose log records carry the request ID and
+// the org the request acts on.
+func LogContext(c *fiber.Ctx) context.Context {
+	requestID, _ := c.Locals("requestid").(string)
+
+	orgID := c.Params("orgId")
+	if orgID == "" {
+		orgID = OrgID(c)
+	}
+	return logger.With(c.UserContext(), logger.RequestIDKey, requestID, logger.OrgIDKey, orgID)
+}
+
+// OrgID returns the org of the request's API key or shopper token. It is
+// empty for the platform key, which acts on the org named in the request.
+func OrgID(c *fiber.Ctx) string {
+	if key, ok := c.Locals(localsKey).(models.APIKey); ok {
+		return key.OrgID
+	}
+	if claims, ok := c.Locals(localsToken).(*ShopperClaims); ok {
+		return claims.OrgID
+	}
+	return ""
+}
+
+// UserID returns the shopper of the request's token, if any.
+func UserID(c *fiber.Ctx) string {
+	if claims, ok := c.Locals(localsShopper).(*ShopperClaims); ok {
+		return claims.Subject
+	}
+	return ""
+}
+
+// SessionID returns the session the request's shopper token is bound to, if
+// any.
+func SessionID(c *fiber.Ctx) string {
+	if claims, ok := c.Locals(localsShopper).(*ShopperClaims); ok {
+		return claims.SessionID
+	}
+	return ""
+}
+
+// Role returns the role the request was admitted with.
+func Role(c *fiber.Ctx) string {
+	role, _ := c.Locals(localsRole).(string)
+	return role
+}
+
+// Principal names who made the request, for role assignments and audit.
+func Principal(c *fiber.Ctx) string {
+	if IsPlatform(c) {
+		return "platform"
+	}
+	if key, ok := c.Locals(localsKey).(models.APIKey); ok {
+		return models.KeyPrincipal(key.ID)
+	}
+	if claims, ok := c.Locals(localsToken).(*ShopperClaims); ok {
+		return models.UserPrincipal(claims.Subject)
+	}
+	return ""
+}
+
+func IsShopper(c *fiber.Ctx) bool {
+	_, ok := c.Locals(localsShopper).(*ShopperClaims)
+	return ok
+}
+
+func IsPlatform(c *fiber.Ctx) bool {
+	platform, _ := c.Locals(localsPlatform).(bool)
+	return platform
+}
+
+func (a *authenticator) RequirePermission(perm string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		raw := bearerKey(c)
+		if raw == "" {
+			return utils.Fail(c, fiber.StatusUnauthorized, "missing api key")
+		}
+
+		if a.isPlatformKey(raw) {
+			c.Locals(localsPlatform, true)
+			c.Locals(localsRole, models.RoleSuperAdmin)
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+		defer cancel()
+
+		if isJWT(raw) {
+			return a.token(ctx, c, raw, perm)
+		}
+
+		key, err := a.verify(ctx, raw)
+		if err != nil {
+			return utils.Fail(c, fiber.StatusUnauthorized, err.Error())
+		}
+
+		if scope := models.PermissionScope(perm); !key.HasScope(scope) {
+			return utils.Fail(c, fiber.StatusForbidden, fmt.Sprintf("api key lacks the %s scope", scope))
+		}
+
+		if !bindParam(c, "orgId", key.OrgID) {
+			return utils.Fail(c, fiber.StatusForbidden, "api key does not belong to this org")
+		}
+
+		role := models.DefaultKeyRole(key)
+		assignment, err := helpers.GetRoleAssignment(ctx, a.rdb, key.OrgID, models.KeyPrincipal(key.ID))
+		if err != nil {
+			return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to load role.%v", err))
+		}
+		if assignment != nil {
+			role = assignment.Role
+		}
+
+		if !models.RoleHasPermission(role, perm) {
+			return utils.Fail(c, fiber.StatusForbidden, fmt.Sprintf("role %s lacks the %s permission", role, perm))
+		}
+
+		// Widget keys ship in the storefront, so anyone can read them. They
+		// only mint anonymous tokens; shopper routes need that token, which
+		// pins the caller to its own user and session.
+		if role == models.RoleWidget && perm != models.PermShopperTokens {
+			return utils.Fail(c, fiber.StatusForbidden, "widget keys can only issue shopper tokens, use a shopper token")
+		}
+
+		c.Locals(localsKey, key)
+		c.Locals(localsRole, role)
+		return c.Next()
+	}
+}
+
+// token admits a JWT. Subjects with a role in the token's org are staff and
+// act with that role; everyone else is a shopper with the widget role,
+// pinned to their own user and any bound session.
+func (a *authenticator) token(ctx context.Context, c *fiber.Ctx, raw string, perm string) error {
+	claims, err := a.jwt.verify(raw)
+	if err != nil {
+		return utils.Fail(c, fiber.StatusUnauthorized, fmt.Sprintf("invalid token.%v", err))
+	}
+
+	if !bindParam(c, "orgId", claims.OrgID) {
+		return utils.Fail(c, fiber.StatusForbidden, "token does not belong to this org")
+	}
+
+	var assignment *models.RoleAssignment
+	if !claims.Anonymous {
+		assignment, err = helpers.GetRoleAssignment(ctx, a.rdb, claims.OrgID, models.UserPrincipal(claims.Subject))
+		if err != nil {
+			return utils.Fail(c, fiber.StatusInternalServerError, fmt.Sprintf("unable to load role.%v", err))
+		}
+	}
+
+	role := models.RoleWidget
+	if assignment != nil {
+		role = assignment.Role
+	} else {
+		if !bindParam(c, "userId", claims.Subject) {
+			return utils.Fail(c, fiber.StatusForbidden, "token does not belong to this user")
+		}
+		if claims.SessionID != "" && !bindParam(c, "sessionId", claims.SessionID) {
+			return utils.Fail(c, fiber.StatusForbidden, "token does not belong to this session")
+		}
+		c.Locals(localsShopper, claims)
+	}
+
+	if !models.RoleHasPermission(role, perm) {
+		return utils.Fail(c, fiber.StatusForbidden, fmt.Sprintf("role %s lacks the %s permission", role, perm))
+	}
+
+	c.Locals(localsToken, claims)
+	c.Locals(localsRole, role)
+	return c.Next()
+}
+
+func (a *authenticator) IssueAnonymousToken(orgID string) (models.ShopperToken, error) {
+	return a.jwt.issueAnonymous(orgID, time.Duration(a.cfg.AnonymousTokenMinutes)*time.Minute)
+}
+
+// bindParam checks that name, when given in the path or query, equals want.
+// Routes taking it as a query parameter get it filled in when missing.
+func bindParam(c *fiber.Ctx, name string, want string) bool {
+	if v := c.Params(name); v != "" && v != want {
+		return false
+	}
+	if v := c.Query(name); v != "" && v != want {
+		return false
+	}
+	c.Request().URI().QueryArgs().Set(name, want)
+	return true
+}
+
+func (a *authenticator) verify(ctx context.Context, raw string) (models.APIKey, error) {
+	keyID, ok := helpers.ParseAPIKeyID(raw)
+	if !ok {
+		return models.APIKey{}, errors.New("malformed api key")
+	}
+
+	key, hash, err := helpers.GetAPIKey(ctx, a.rdb, keyID)
+	if errors.Is(err, helpers.ErrAPIKeyNotFound) {
+		return models.APIKey{}, errors.New("invalid api key")
+	}
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("unable to verify api key.%v", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(helpers.HashAPIKey(raw)), []byte(hash)) != 1 {
+		return models.APIKey{}, errors.New("invalid api key")
+	}
+
+	if !key.Active(time.Now()) {
+		return models.APIKey{}, errors.New("api key is revoked or expired")
+	}
+	return key, nil
+}
+
+func (a *authenticator) isPlatformKey(raw string) bool {
+	return a.cfg.PlatformKey != "" && subtle.ConstantTimeCompare([]byte(raw), []byte(a.cfg.PlatformKey)) == 1
+}
+
+// isJWT tells shopper tokens apart from API keys by their three segments.
+func isJWT(raw string) bool {
+	return !strings.HasPrefix(raw, "ek_") && strings.Count(raw, ".") == 2
+}
+
+// bearerKey reads the key from "Authorization: Bearer <key>" or X-API-Key.
+func bearerKey(c *fiber.Ctx) string {
+	if key, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "); ok {
+		return strings.TrimSpace(key)
+	}
+	return strings.TrimSpace(c.Get("X-API-Key"))
+}
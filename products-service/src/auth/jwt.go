@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// ShopperClaims identify a shopper. The subject is the user id.
+type ShopperClaims struct {
+	OrgID string `json:"org"`
+	// SessionID binds the token to one chat session. Tokens without it may
+	// use any of the user's sessions.
+	SessionID string `json:"sid,omitempty"`
+	Anonymous bool   `json:"anon,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type jwtVerifier struct {
+	secret  []byte
+	rsaKeys map[string]*rsa.PublicKey
+	issuer  string
+}
+
+func newJWTVerifier(secret string, jwksFile string, issuer string) (*jwtVerifier, error) {
+	v := &jwtVerifier{secret: []byte(secret), issuer: issuer, rsaKeys: map[string]*rsa.PublicKey{}}
+
+	if jwksFile != "" {
+		keys, err := loadJWKS(jwksFile)
+		if err != nil {
+			return nil, err
+		}
+		v.rsaKeys = keys
+	}
+	return v, nil
+}
+
+func (v *jwtVerifier) verify(raw string) (*ShopperClaims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if v.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.issuer))
+	}
+
+	claims := &ShopperClaims{}
+	if _, err := jwt.ParseWithClaims(raw, claims, v.key, opts...); err != nil {
+		return nil, err
+	}
+
+	if claims.Subject == "" || claims.OrgID == "" {
+		return nil, errors.New("token is missing the sub or org claim")
+	}
+	return claims, nil
+}
+
+// key picks the verification key by algorithm so an RS256 public key can
+// never be used as an HS256 secret.
+func (v *jwtVerifier) key(t *jwt.Token) (any, error) {
+	switch t.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if len(v.secret) == 0 {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		return v.secret, nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := t.Header["kid"].(string)
+		if key, ok := v.rsaKeys[kid]; ok {
+			return key, nil
+		}
+		if kid == "" && len(v.rsaKeys) == 1 {
+			for _, key := range v.rsaKeys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+}
+
+func (v *jwtVerifier) issueAnonymous(orgID string, ttl time.Duration) (models.ShopperToken, error) {
+	if len(v.secret) == 0 {
+		return models.ShopperToken{}, errors.New("no jwt secret configured")
+	}
+
+	now := time.Now()
+	claims := ShopperClaims{
+		OrgID:     orgID,
+		SessionID: uuid.New().String(),
+		Anonymous: true,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "anon_" + uuid.New().String(),
+			Issuer:    v.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(v.secret)
+	if err != nil {
+		return models.ShopperToken{}, err
+	}
+
+	return models.ShopperToken{
+		Token:     token,
+		UserID:    claims.Subject,
+		SessionID: claims.SessionID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+type jwkSet struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// loadJWKS reads the RSA signing keys of a JWK set file.
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	byt, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwks file: %v", err)
+	}
+
+	var set jwkSet
+	if err := json.Unmarshal(byt, &set); err != nil {
+		return nil, fmt.Errorf("failed to decode jwks file: %v", err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus for key %q: %v", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent for key %q: %v", k.Kid, err)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("jwks file has no RSA signing keys")
+	}
+	return keys, nil
+}
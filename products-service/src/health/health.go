@@ -0,0 +1,105 @@
+// Package health runs the dependency checks behind the readiness probe.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+)
+
+// Check probes one dependency. Optional checks are reported but do not make
+// the service unready. A check with CacheFor set reuses its last result for
+// that long, for dependencies too slow or costly to probe on every request.
+type Check struct {
+	Name     string
+	Optional bool
+	CacheFor time.Duration
+	Run      func(ctx context.Context) error
+}
+
+type Checker interface {
+	// Ready runs every check concurrently, each bounded by the checker's
+	// timeout.
+	Ready(ctx context.Context) models.HealthReport
+}
+
+type checker struct {
+	checks  []Check
+	timeout time.Duration
+
+	mu     sync.Mutex
+	cached map[string]cachedResult
+}
+
+type cachedResult struct {
+	health models.ComponentHealth
+	at     time.Time
+}
+
+func NewChecker(timeout time.Duration, checks ...Check) Checker {
+	return &checker{checks: checks, timeout: timeout, cached: map[string]cachedResult{}}
+}
+
+func (c *checker) Ready(ctx context.Context) models.HealthReport {
+	report := models.HealthReport{
+		Status:     models.HealthUp,
+		Components: make(map[string]models.ComponentHealth, len(c.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			health := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[check.Name] = health
+			if health.Status != models.HealthUp && !check.Optional {
+				report.Status = models.HealthDown
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c *checker) run(ctx context.Context, check Check) models.ComponentHealth {
+	if check.CacheFor > 0 {
+		c.mu.Lock()
+		last, ok := c.cached[check.Name]
+		c.mu.Unlock()
+		if ok && time.Since(last.at) < check.CacheFor {
+			last.health.Cached = true
+			return last.health
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+
+	health := models.ComponentHealth{
+		Status:    models.HealthUp,
+		LatencyMs: time.Since(start).Milliseconds(),
+		Optional:  check.Optional,
+	}
+	if err != nil {
+		health.Status = models.HealthDown
+		health.Error = err.Error()
+	}
+
+	if check.CacheFor > 0 {
+		c.mu.Lock()
+		c.cached[check.Name] = cachedResult{health: health, at: time.Now()}
+		c.mu.Unlock()
+	}
+	return health
+}
@@ -0,0 +1,134 @@
+package helpers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const apiKeyPrefix = "ek_"
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// storedAPIKey is what is kept in Redis: the key metadata and the SHA-256 of
+// the plaintext key. Keys are high-entropy random strings, so a fast hash is
+// sufficient.
+type storedAPIKey struct {
+	models.APIKey
+	Hash string `json:"hash"`
+}
+
+// GenerateAPIKey returns a new key id and plaintext key of the form
+// ek_<id>_<secret>. The id is the lookup prefix.
+func GenerateAPIKey() (string, string, error) {
+	id := make([]byte, 6)
+	secret := make([]byte, 24)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	keyID := hex.EncodeToString(id)
+	return keyID, apiKeyPrefix + keyID + "_" + hex.EncodeToString(secret), nil
+}
+
+// ParseAPIKeyID extracts the key id from a plaintext key.
+func ParseAPIKeyID(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, apiKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	id, _, ok := strings.Cut(rest, "_")
+	return id, ok && id != ""
+}
+
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func SaveAPIKey(ctx context.Context, rdb *redis.Client, key models.APIKey, hash string) error {
+	byt, err := json.Marshal(storedAPIKey{APIKey: key, Hash: hash})
+	if err != nil {
+		return err
+	}
+
+	pipe := rdb.TxPipeline()
+	pipe.Set(ctx, GetAPIKeyKey(key.ID), byt, 0)
+	pipe.SAdd(ctx, GetOrgAPIKeysKey(key.OrgID), key.ID)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// GetAPIKey returns the key and its stored hash.
+func GetAPIKey(ctx context.Context, rdb *redis.Client, keyID string) (models.APIKey, string, error) {
+	val, err := rdb.Get(ctx, GetAPIKeyKey(keyID)).Result()
+	if err == redis.Nil {
+		return models.APIKey{}, "", ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+
+	var stored storedAPIKey
+	if err := json.Unmarshal([]byte(val), &stored); err != nil {
+		return models.APIKey{}, "", fmt.Errorf("failed to decode api key: %v", err)
+	}
+	return stored.APIKey, stored.Hash, nil
+}
+
+// RevokeAPIKey marks the key revoked. Revoked keys are kept so they still
+// show up when listing.
+func RevokeAPIKey(ctx context.Context, rdb *redis.Client, keyID string) error {
+	key, hash, err := GetAPIKey(ctx, rdb, keyID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+	return SaveAPIKey(ctx, rdb, key, hash)
+}
+
+// ListAPIKeys returns the org's keys, newest first, including revoked ones.
+func ListAPIKeys(ctx context.Context, rdb *redis.Client, orgID string) ([]models.APIKey, error) {
+	ids, err := rdb.SMembers(ctx, GetOrgAPIKeysKey(orgID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]models.APIKey, 0, len(ids))
+	for _, id := range ids {
+		key, _, err := GetAPIKey(ctx, rdb, id)
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func GetAPIKeyKey(keyID string) string {
+	return fmt.Sprintf("api_key:%v", keyID)
+}
+
+func GetOrgAPIKeysKey(orgID string) string {
+	return fmt.Sprintf("org_api_keys:%v", orgID)
+}
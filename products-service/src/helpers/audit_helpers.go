@@ -0,0 +1,103 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// auditBatch is how many events are read per round trip while filtering.
+const auditBatch = 200
+
+// AppendAuditEvent stores an event in the org's audit log, scored by time.
+// Events are never updated or removed.
+func AppendAuditEvent(ctx context.Context, rdb *redis.Client, event models.AuditEvent) error {
+	byt, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return rdb.ZAdd(ctx, GetAuditLogKey(event.OrgID), redis.Z{
+		Score:  float64(event.Time.UnixMilli()),
+		Member: byt,
+	}).Err()
+}
+
+// QueryAuditEvents returns the org's events newest first, filtered by actor
+// and time range. cursor is the NextCursor of the previous page, or zero for
+// the first page.
+//
+// Several events can share a millisecond, so a cursor also counts how many
+// of the events at its millisecond were already scanned. Events at the same
+// score are ordered by member, which never changes once stored.
+func QueryAuditEvents(ctx context.Context, rdb *redis.Client, q models.AuditQuery, cursor models.AuditCursor) (models.AuditPage, error) {
+	max := "+inf"
+	if !q.To.IsZero() {
+		max = strconv.FormatInt(q.To.UnixMilli(), 10)
+	}
+
+	var offset int64
+	last, atLast := int64(-1), int64(0)
+	if cursor.Time > 0 {
+		max = strconv.FormatInt(cursor.Time, 10)
+		offset = cursor.Skip
+		last, atLast = cursor.Time, cursor.Skip
+	}
+
+	min := "-inf"
+	if !q.From.IsZero() {
+		min = strconv.FormatInt(q.From.UnixMilli(), 10)
+	}
+
+	page := models.AuditPage{Events: []models.AuditEvent{}}
+
+	for len(page.Events) < q.Limit {
+		vals, err := rdb.ZRevRangeByScoreWithScores(ctx, GetAuditLogKey(q.OrgID), &redis.ZRangeBy{
+			Max:    max,
+			Min:    min,
+			Offset: offset,
+			Count:  auditBatch,
+		}).Result()
+		if err != nil {
+			return page, err
+		}
+
+		for _, v := range vals {
+			score := int64(math.Round(v.Score))
+			if score != last {
+				last, atLast = score, 0
+			}
+			atLast++
+
+			member, _ := v.Member.(string)
+
+			var event models.AuditEvent
+			if err := json.Unmarshal([]byte(member), &event); err != nil {
+				continue
+			}
+			if q.Actor != "" && event.Actor != q.Actor {
+				continue
+			}
+
+			page.Events = append(page.Events, event)
+			if len(page.Events) == q.Limit {
+				page.NextCursor = models.AuditCursor{Time: last, Skip: atLast}.String()
+				return page, nil
+			}
+		}
+
+		if len(vals) < auditBatch {
+			break
+		}
+		offset += auditBatch
+	}
+	return page, nil
+}
+
+func GetAuditLogKey(orgID string) string {
+	return fmt.Sprintf("audit_log:%v", orgID)
+}
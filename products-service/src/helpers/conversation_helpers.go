@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// maxChatTurns caps how many turns are kept per session.
+const maxChatTurns = 200
+
+// AppendChatTurn stores a turn at the end of the session history and bumps
+// the session in the user's session index. ttl is refreshed on every turn.
+func AppendChatTurn(ctx context.Context, rdb *redis.Client, params models.AiQueryParams, turn models.ChatTurn, ttl time.Duration) error {
+	byt, err := json.Marshal(turn)
+	if err != nil {
+		return err
+	}
+
+	historyKey := GetChatHistoryKey(params)
+	sessionsKey := GetUserSessionsKey(params.UserID, params.OrgID)
+
+	pipe := rdb.TxPipeline()
+	pipe.RPush(ctx, historyKey, byt)
+	pipe.LTrim(ctx, historyKey, -maxChatTurns, -1)
+	pipe.Expire(ctx, historyKey, ttl)
+	pipe.ZAdd(ctx, sessionsKey, redis.Z{Score: float64(turn.StartedAt.Unix()), Member: params.SessionID})
+	pipe.Expire(ctx, sessionsKey, ttl)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store chat turn: %v", err)
+	}
+	return nil
+}
+
+func GetChatTurns(ctx context.Context, rdb *redis.Client, params models.AiQueryParams, offset int, limit int) ([]models.ChatTurn, error) {
+	vals, err := rdb.LRange(ctx, GetChatHistoryKey(params), int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	turns := make([]models.ChatTurn, 0, len(vals))
+	for _, v := range vals {
+		var turn models.ChatTurn
+		if err := json.Unmarshal([]byte(v), &turn); err != nil {
+			continue
+		}
+		turns = append(turns, turn)
+	}
+	return turns, nil
+}
+
+// ListUserSessions returns the user's sessions, most recently active first.
+// Sessions whose history has expired are pruned from the index.
+func ListUserSessions(ctx context.Context, rdb *redis.Client, userID string, orgID string) ([]models.SessionSummary, error) {
+	sessionsKey := GetUserSessionsKey(userID, orgID)
+
+	members, err := rdb.ZRevRangeWithScores(ctx, sessionsKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]models.SessionSummary, 0, len(members))
+	for _, m := range members {
+		sessionID, _ := m.Member.(string)
+		params := models.AiQueryParams{UserID: userID, OrgID: orgID, SessionID: sessionID}
+
+		turns, err := rdb.LLen(ctx, GetChatHistoryKey(params)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if turns == 0 {
+			rdb.ZRem(ctx, sessionsKey, sessionID)
+			continue
+		}
+
+		sessions = append(sessions, models.SessionSummary{
+			SessionID:  sessionID,
+			LastActive: time.Unix(int64(m.Score), 0),
+			Turns:      turns,
+		})
+	}
+	return sessions, nil
+}
+
+func GetChatHistoryKey(params models.AiQueryParams) string {
+	return fmt.Sprintf("chat_history:%v_%v_%v", params.UserID, params.OrgID, params.SessionID)
+}
+
+func GetUserSessionsKey(userID string, orgID string) string {
+	return fmt.Sprintf("user_sessions:%v_%v", userID, orgID)
+}
@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// lockRetryDelay is how often a waiting LockProduct retries.
+const lockRetryDelay = 50 * time.Millisecond
+
+// unlockScript releases a lock only if ARGV[1] still holds it, so a holder
+// whose lock expired cannot release the next one.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// LockProduct takes the lock that serializes writes to a stored product,
+// waiting until it is free or ctx is done. The lock expires after ttl in case
+// its holder dies.
+func LockProduct(ctx context.Context, rdb *redis.Client, orgID string, productID string, ttl time.Duration) (unlock func(), err error) {
+	key := GetProductLockKey(orgID, productID)
+	token := uuid.NewString()
+
+	for {
+		ok, err := rdb.SetNX(ctx, key, token, ttl).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetryDelay):
+		}
+	}
+
+	return func() {
+		// The caller's context may be done by the time it unlocks.
+		unlockCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		_ = unlockScript.Run(unlockCtx, rdb, []string{key}, token).Err()
+	}, nil
+}
+
+func GetProductLockKey(orgID string, productID string) string {
+	return fmt.Sprintf("product_lock:%v_%v", orgID, productID)
+}
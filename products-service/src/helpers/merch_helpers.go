@@ -0,0 +1,107 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// merchAuditSize caps how many rule audits are kept per org.
+const merchAuditSize = 1000
+
+func GetMerchRules(ctx context.Context, rdb *redis.Client, orgID string) ([]models.MerchRule, error) {
+	vals, err := rdb.HGetAll(ctx, GetMerchRulesKey(orgID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]models.MerchRule, 0, len(vals))
+	for _, v := range vals {
+		var rule models.MerchRule
+		if err := json.Unmarshal([]byte(v), &rule); err != nil {
+			return nil, fmt.Errorf("failed to decode merchandising rule: %v", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].UpdatedAt.Before(rules[j].UpdatedAt)
+	})
+
+	return rules, nil
+}
+
+func GetMerchRule(ctx context.Context, rdb *redis.Client, orgID string, ruleID string) (*models.MerchRule, error) {
+	val, err := rdb.HGet(ctx, GetMerchRulesKey(orgID), ruleID).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rule models.MerchRule
+	if err := json.Unmarshal([]byte(val), &rule); err != nil {
+		return nil, fmt.Errorf("failed to decode merchandising rule: %v", err)
+	}
+	return &rule, nil
+}
+
+func SetMerchRule(ctx context.Context, rdb *redis.Client, orgID string, rule models.MerchRule) error {
+	byt, err := json.Marshal(rule)
+	if err != nil {
+		return err
+	}
+	return rdb.HSet(ctx, GetMerchRulesKey(orgID), rule.ID, byt).Err()
+}
+
+// DeleteMerchRule reports whether the rule existed.
+func DeleteMerchRule(ctx context.Context, rdb *redis.Client, orgID string, ruleID string) (bool, error) {
+	n, err := rdb.HDel(ctx, GetMerchRulesKey(orgID), ruleID).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func PushMerchAudit(ctx context.Context, rdb *redis.Client, orgID string, audit models.MerchAudit) error {
+	byt, err := json.Marshal(audit)
+	if err != nil {
+		return err
+	}
+
+	key := GetMerchAuditKey(orgID)
+	if err := rdb.LPush(ctx, key, byt).Err(); err != nil {
+		return fmt.Errorf("failed to push merchandising audit: %v", err)
+	}
+	return rdb.LTrim(ctx, key, 0, merchAuditSize-1).Err()
+}
+
+func GetMerchAudit(ctx context.Context, rdb *redis.Client, orgID string, limit int) ([]models.MerchAudit, error) {
+	vals, err := rdb.LRange(ctx, GetMerchAuditKey(orgID), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	audits := make([]models.MerchAudit, 0, len(vals))
+	for _, v := range vals {
+		var audit models.MerchAudit
+		if err := json.Unmarshal([]byte(v), &audit); err != nil {
+			continue
+		}
+		audits = append(audits, audit)
+	}
+	return audits, nil
+}
+
+func GetMerchRulesKey(orgID string) string {
+	return fmt.Sprintf("merch_rules:%v", orgID)
+}
+
+func GetMerchAuditKey(orgID string) string {
+	return fmt.Sprintf("merch_audit:%v", orgID)
+}
@@ -0,0 +1,47 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// GetUserPreferences returns the shopper's stored preferences, or an empty
+// profile when none have been recorded.
+func GetUserPreferences(ctx context.Context, rdb *redis.Client, userID string, orgID string) (models.ShopperPreferences, error) {
+	val, err := rdb.Get(ctx, GetUserPreferencesKey(userID, orgID)).Result()
+	if err == redis.Nil {
+		return models.ShopperPreferences{}, nil
+	}
+	if err != nil {
+		return models.ShopperPreferences{}, err
+	}
+
+	var prefs models.ShopperPreferences
+	if err := json.Unmarshal([]byte(val), &prefs); err != nil {
+		return models.ShopperPreferences{}, fmt.Errorf("failed to decode preferences: %v", err)
+	}
+	return prefs, nil
+}
+
+func SetUserPreferences(ctx context.Context, rdb *redis.Client, userID string, orgID string, prefs models.ShopperPreferences, ttl time.Duration) error {
+	prefs.UpdatedAt = time.Now()
+	byt, err := json.Marshal(prefs)
+	if err != nil {
+		return err
+	}
+	return rdb.Set(ctx, GetUserPreferencesKey(userID, orgID), byt, ttl).Err()
+}
+
+func DeleteUserPreferences(ctx context.Context, rdb *redis.Client, userID string, orgID string) (int, error) {
+	n, err := rdb.Del(ctx, GetUserPreferencesKey(userID, orgID)).Result()
+	return int(n), err
+}
+
+func GetUserPreferencesKey(userID string, orgID string) string {
+	return fmt.Sprintf("user_prefs:%v_%v", userID, orgID)
+}
@@ -0,0 +1,233 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// GetRetentionPolicy returns the org's retention policy, falling back to the
+// defaults when none has been stored.
+func GetRetentionPolicy(ctx context.Context, rdb *redis.Client, orgID string) (models.RetentionPolicy, error) {
+	val, err := rdb.Get(ctx, GetRetentionPolicyKey(orgID)).Result()
+	if err == redis.Nil {
+		return models.DefaultRetentionPolicy(), nil
+	}
+	if err != nil {
+		return models.DefaultRetentionPolicy(), err
+	}
+
+	policy := models.DefaultRetentionPolicy()
+	if err := json.Unmarshal([]byte(val), &policy); err != nil {
+		return models.DefaultRetentionPolicy(), fmt.Errorf("failed to decode retention policy: %v", err)
+	}
+	return policy, nil
+}
+
+func SetRetentionPolicy(ctx context.Context, rdb *redis.Client, orgID string, policy models.RetentionPolicy) error {
+	byt, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	return rdb.Set(ctx, GetRetentionPolicyKey(orgID), byt, 0).Err()
+}
+
+// TrackUserSession adds the session to the user's data index, which erasure
+// walks to find the session's keys. The index lives as long as the
+// longest-lived data it covers.
+func TrackUserSession(ctx context.Context, rdb *redis.Client, params models.AiQueryParams, retention models.RetentionPolicy) error {
+	key := GetUserDataSessionsKey(params.UserID, params.OrgID)
+
+	pipe := rdb.TxPipeline()
+	pipe.SAdd(ctx, key, params.SessionID)
+	pipe.Expire(ctx, key, retention.LongestTTL())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to track session: %v", err)
+	}
+	return nil
+}
+
+// EraseUserData deletes every Redis key holding the user's data in the org
+// and strips the user from audit records. Counts are keyed by key prefix.
+//
+// Session keys are found through the user's session indexes and then by a
+// SCAN of the session key patterns, which also finds keys the indexes never
+// saw. Keys are joined with "_", so when IDs contain one the sweep can match
+// another user's session keys too; those are erased as well rather than risk
+// keeping the requester's data.
+func EraseUserData(ctx context.Context, rdb *redis.Client, orgID string, userID string) (map[string]int, int, error) {
+	deleted := map[string]int{}
+
+	dataKey := GetUserDataSessionsKey(userID, orgID)
+	sessionsKey := GetUserSessionsKey(userID, orgID)
+
+	tracked, err := rdb.SMembers(ctx, dataKey).Result()
+	if err != nil {
+		return deleted, 0, err
+	}
+	listed, err := rdb.ZRange(ctx, sessionsKey, 0, -1).Result()
+	if err != nil {
+		return deleted, 0, err
+	}
+
+	seen := map[string]bool{}
+	for _, sessionID := range append(tracked, listed...) {
+		if seen[sessionID] {
+			continue
+		}
+		seen[sessionID] = true
+
+		n, err := deleteSessionKeys(ctx, rdb, models.AiQueryParams{UserID: userID, OrgID: orgID, SessionID: sessionID})
+		for name, count := range n {
+			deleted[name] += count
+		}
+		if err != nil {
+			return deleted, 0, err
+		}
+	}
+
+	swept, err := sweepSessionKeys(ctx, rdb, userID, orgID)
+	for name, count := range swept {
+		deleted[name] += count
+	}
+	if err != nil {
+		return deleted, 0, err
+	}
+
+	n, err := rdb.Del(ctx, sessionsKey).Result()
+	if err != nil {
+		return deleted, 0, err
+	}
+	deleted["user_sessions"] += int(n)
+
+	if err := rdb.Del(ctx, dataKey).Err(); err != nil {
+		return deleted, 0, err
+	}
+
+	n, err = rdb.Del(ctx, GetUserChatRateKey(orgID, userID)).Result()
+	if err != nil {
+		return deleted, 0, err
+	}
+	deleted["rate_chat_user"] += int(n)
+
+	prefs, err := DeleteUserPreferences(ctx, rdb, userID, orgID)
+	if err != nil {
+		return deleted, 0, err
+	}
+	deleted["user_prefs"] += prefs
+
+	usage, err := removeUserUsage(ctx, rdb, orgID, userID)
+	if err != nil {
+		return deleted, 0, err
+	}
+	deleted["llm_usage_users"] += usage
+
+	removed, err := removeUserFromMerchAudit(ctx, rdb, orgID, userID)
+	if err != nil {
+		return deleted, 0, err
+	}
+
+	return deleted, removed, nil
+}
+
+// DeleteSession deletes a single session's queries, summary and history.
+func DeleteSession(ctx context.Context, rdb *redis.Client, params models.AiQueryParams) (map[string]int, error) {
+	deleted, err := deleteSessionKeys(ctx, rdb, params)
+	if err != nil {
+		return deleted, err
+	}
+
+	pipe := rdb.TxPipeline()
+	pipe.ZRem(ctx, GetUserSessionsKey(params.UserID, params.OrgID), params.SessionID)
+	pipe.SRem(ctx, GetUserDataSessionsKey(params.UserID, params.OrgID), params.SessionID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return deleted, err
+	}
+	return deleted, nil
+}
+
+func deleteSessionKeys(ctx context.Context, rdb *redis.Client, params models.AiQueryParams) (map[string]int, error) {
+	deleted := map[string]int{}
+	keys := map[string]string{
+		"user_queries": GetUserQueriesKey(params),
+		"user_chat":    GetUserChatKey(params),
+		"chat_history": GetChatHistoryKey(params),
+	}
+
+	for name, key := range keys {
+		n, err := rdb.Del(ctx, key).Result()
+		if err != nil {
+			return deleted, err
+		}
+		deleted[name] = int(n)
+	}
+	return deleted, nil
+}
+
+// sessionKeyPrefixes are the prefixes of the per-session keys built by
+// GetUserQueriesKey, GetUserChatKey and GetChatHistoryKey.
+var sessionKeyPrefixes = []string{"user_queries", "user_chat", "chat_history"}
+
+// sweepSessionKeys deletes the user's session keys by pattern, covering keys
+// written before the session indexes existed or while tracking failed.
+func sweepSessionKeys(ctx context.Context, rdb *redis.Client, userID string, orgID string) (map[string]int, error) {
+	deleted := map[string]int{}
+
+	for _, prefix := range sessionKeyPrefixes {
+		pattern := fmt.Sprintf("%s:%s_%s_*", prefix, escapeGlob(userID), escapeGlob(orgID))
+
+		iter := rdb.Scan(ctx, 0, pattern, 100).Iterator()
+		for iter.Next(ctx) {
+			n, err := rdb.Del(ctx, iter.Val()).Result()
+			if err != nil {
+				return deleted, err
+			}
+			deleted[prefix] += int(n)
+		}
+		if err := iter.Err(); err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+func removeUserFromMerchAudit(ctx context.Context, rdb *redis.Client, orgID string, userID string) (int, error) {
+	key := GetMerchAuditKey(orgID)
+
+	vals, err := rdb.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, v := range vals {
+		var audit models.MerchAudit
+		if err := json.Unmarshal([]byte(v), &audit); err != nil || audit.UserID != userID {
+			continue
+		}
+		n, err := rdb.LRem(ctx, key, 0, v).Result()
+		if err != nil {
+			return removed, err
+		}
+		removed += int(n)
+	}
+	return removed, nil
+}
+
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+func escapeGlob(s string) string {
+	return globEscaper.Replace(s)
+}
+
+func GetRetentionPolicyKey(orgID string) string {
+	return fmt.Sprintf("retention_policy:%v", orgID)
+}
+
+func GetUserDataSessionsKey(userID string, orgID string) string {
+	return fmt.Sprintf("user_data_sessions:%v_%v", userID, orgID)
+}
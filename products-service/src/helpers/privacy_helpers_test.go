@@ -0,0 +1,64 @@
+package helpers
+
+import (
+	"context"
+	"maps"
+	"testing"
+)
+
+func TestEraseUserDataFindsUntrackedSessions(t *testing.T) {
+	f, rdb := newFakeRedis(t, map[string]any{
+		// Session s1 is in the index.
+		"user_data_sessions:u1_o1": map[string]bool{"s1": true},
+		"user_queries:u1_o1_s1":    []string{"red shoes"},
+		"user_chat:u1_o1_s1":       "summary",
+		// Session s2 was written before the index existed.
+		"user_queries:u1_o1_s2": []string{"blue shoes"},
+		"user_chat:u1_o1_s2":    "summary",
+		"chat_history:u1_o1_s2": []string{"{}"},
+		// Other users and orgs.
+		"user_queries:u2_o1_s3": []string{"hats"},
+		"user_queries:u1_o2_s4": []string{"hats"},
+	})
+
+	deleted, _, err := EraseUserData(context.Background(), rdb, "o1", "u1")
+	if err != nil {
+		t.Fatalf("EraseUserData: %v", err)
+	}
+
+	for _, key := range []string{"user_queries:u1_o1_s1", "user_chat:u1_o1_s1", "user_queries:u1_o1_s2", "user_chat:u1_o1_s2", "chat_history:u1_o1_s2", "user_data_sessions:u1_o1"} {
+		if f.has(key) {
+			t.Errorf("%s was not erased", key)
+		}
+	}
+	for _, key := range []string{"user_queries:u2_o1_s3", "user_queries:u1_o2_s4"} {
+		if !f.has(key) {
+			t.Errorf("%s of another user was erased", key)
+		}
+	}
+
+	want := map[string]int{"user_queries": 2, "user_chat": 2, "chat_history": 1}
+	for name, n := range want {
+		if deleted[name] != n {
+			t.Errorf("deleted[%s] = %d, want %d (all: %v)", name, deleted[name], n, deleted)
+		}
+	}
+}
+
+func TestEraseUserDataEscapesPatterns(t *testing.T) {
+	data := map[string]any{
+		"user_queries:u1_o1_s1": []string{"red shoes"},
+		"user_chat:u1_o1_s1":    "summary",
+	}
+	f, rdb := newFakeRedis(t, maps.Clone(data))
+
+	if _, _, err := EraseUserData(context.Background(), rdb, "o?", "u*"); err != nil {
+		t.Fatalf("EraseUserData: %v", err)
+	}
+
+	for key := range data {
+		if !f.has(key) {
+			t.Errorf("%s was erased by a wildcard user ID", key)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript admits a request when fewer than ARGV[2] requests were
+// admitted in the last ARGV[3] milliseconds. It returns 0 when admitted,
+// otherwise the milliseconds until the oldest request leaves the window.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+
+redis.call("ZREMRANGEBYSCORE", key, 0, now - window)
+
+if redis.call("ZCARD", key) >= limit then
+	local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+	return math.max(1, tonumber(oldest[2]) + window - now)
+end
+
+redis.call("ZADD", key, now, ARGV[4])
+redis.call("PEXPIRE", key, window)
+return 0
+`)
+
+// counterScript adds ARGV[1] to a counter unless that would take it past
+// ARGV[2]. It returns 1 when the amount was added.
+var counterScript = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+if current + tonumber(ARGV[1]) > tonumber(ARGV[2]) then
+	return 0
+end
+redis.call("INCRBY", KEYS[1], ARGV[1])
+redis.call("PEXPIRE", KEYS[1], ARGV[3])
+return 1
+`)
+
+// AllowSlidingWindow records a request against key if fewer than limit were
+// made within window. When it is refused, retryAfter says when to retry.
+func AllowSlidingWindow(ctx context.Context, rdb *redis.Client, key string, limit int, window time.Duration, requestID string) (bool, time.Duration, error) {
+	now := time.Now().UnixMilli()
+	member := fmt.Sprintf("%d-%s", now, requestID)
+
+	wait, err := slidingWindowScript.Run(ctx, rdb, []string{key}, now, limit, window.Milliseconds(), member).Int64()
+	if err != nil {
+		return false, 0, err
+	}
+	return wait == 0, time.Duration(wait) * time.Millisecond, nil
+}
+
+// ReserveDailyUploads counts n uploaded products against the org's daily
+// limit and reports whether they fit.
+func ReserveDailyUploads(ctx context.Context, rdb *redis.Client, orgID string, n int, limit int) (bool, error) {
+	now := time.Now().UTC()
+	ok, err := counterScript.Run(ctx, rdb, []string{GetDailyUploadsKey(orgID, now)}, n, limit, (time.Hour * 48).Milliseconds()).Int64()
+	if err != nil {
+		return false, err
+	}
+	return ok == 1, nil
+}
+
+func GetDailyUploads(ctx context.Context, rdb *redis.Client, orgID string) (int, error) {
+	n, err := rdb.Get(ctx, GetDailyUploadsKey(orgID, time.Now().UTC())).Int()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return n, err
+}
+
+// GetMonthlyTokens returns the tokens the org has used in the current UTC
+// month.
+func GetMonthlyTokens(ctx context.Context, rdb *redis.Client, orgID string) (int64, error) {
+	n, err := rdb.Get(ctx, GetMonthlyTokensKey(orgID, time.Now().UTC())).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return n, err
+}
+
+func GetOrgPlan(ctx context.Context, rdb *redis.Client, orgID string) (string, error) {
+	plan, err := rdb.Get(ctx, GetOrgPlanKey(orgID)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return plan, err
+}
+
+func SetOrgPlan(ctx context.Context, rdb *redis.Client, orgID string, plan string) error {
+	return rdb.Set(ctx, GetOrgPlanKey(orgID), plan, 0).Err()
+}
+
+func GetOrgPlanKey(orgID string) string {
+	return fmt.Sprintf("org_plan:%v", orgID)
+}
+
+func GetChatRateKey(orgID string) string {
+	return fmt.Sprintf("rate_chat:%v", orgID)
+}
+
+func GetUserChatRateKey(orgID string, userID string) string {
+	return fmt.Sprintf("rate_chat_user:%v_%v", userID, orgID)
+}
+
+func GetDailyUploadsKey(orgID string, day time.Time) string {
+	return fmt.Sprintf("uploads_day:%v:%v", orgID, day.Format("2006-01-02"))
+}
+
+func GetMonthlyTokensKey(orgID string, month time.Time) string {
+	return fmt.Sprintf("llm_tokens_month:%v:%v", orgID, month.Format("2006-01"))
+}
@@ -0,0 +1,65 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// GetRoleAssignment returns the principal's role in the org, or nil when it
+// has none.
+func GetRoleAssignment(ctx context.Context, rdb *redis.Client, orgID string, principal string) (*models.RoleAssignment, error) {
+	val, err := rdb.HGet(ctx, GetOrgRolesKey(orgID), principal).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var assignment models.RoleAssignment
+	if err := json.Unmarshal([]byte(val), &assignment); err != nil {
+		return nil, fmt.Errorf("failed to decode role assignment: %v", err)
+	}
+	return &assignment, nil
+}
+
+func SetRoleAssignment(ctx context.Context, rdb *redis.Client, orgID string, assignment models.RoleAssignment) error {
+	byt, err := json.Marshal(assignment)
+	if err != nil {
+		return err
+	}
+	return rdb.HSet(ctx, GetOrgRolesKey(orgID), assignment.Principal, byt).Err()
+}
+
+func DeleteRoleAssignment(ctx context.Context, rdb *redis.Client, orgID string, principal string) (bool, error) {
+	n, err := rdb.HDel(ctx, GetOrgRolesKey(orgID), principal).Result()
+	return n > 0, err
+}
+
+func ListRoleAssignments(ctx context.Context, rdb *redis.Client, orgID string) ([]models.RoleAssignment, error) {
+	vals, err := rdb.HGetAll(ctx, GetOrgRolesKey(orgID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	assignments := make([]models.RoleAssignment, 0, len(vals))
+	for _, v := range vals {
+		var assignment models.RoleAssignment
+		if err := json.Unmarshal([]byte(v), &assignment); err != nil {
+			continue
+		}
+		assignments = append(assignments, assignment)
+	}
+
+	sort.Slice(assignments, func(i, j int) bool { return assignments[i].Principal < assignments[j].Principal })
+	return assignments, nil
+}
+
+func GetOrgRolesKey(orgID string) string {
+	return fmt.Sprintf("org_roles:%v", orgID)
+}
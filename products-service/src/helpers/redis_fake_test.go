@@ -0,0 +1,198 @@
+package helpers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeRedis is an in-memory server for the Redis commands the helpers under
+// test use. Values are strings, lists ([]string), sets (map[string]bool) and
+// hashes (map[string]string). Sorted sets are stored as lists in score order.
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]any
+}
+
+// newFakeRedis starts a fake server holding data and returns a client for it.
+func newFakeRedis(t *testing.T, data map[string]any) (*fakeRedis, *redis.Client) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	f := &fakeRedis{data: data}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+
+	rdb := redis.NewClient(&redis.Options{Addr: ln.Addr().String(), Protocol: 2, DisableIdentity: true})
+	t.Cleanup(func() { rdb.Close() })
+	return f, rdb
+}
+
+func (f *fakeRedis) has(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.data[key]
+	return ok
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.exec(w, args)
+		f.mu.Unlock()
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) exec(w *bufio.Writer, args []string) {
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		fmt.Fprint(w, "+PONG\r\n")
+	case "DEL":
+		n := 0
+		for _, key := range args[1:] {
+			if _, ok := f.data[key]; ok {
+				delete(f.data, key)
+				n++
+			}
+		}
+		writeInt(w, n)
+	case "SCAN":
+		// Every match is returned in one pass.
+		pattern := "*"
+		for i := 2; i+1 < len(args); i += 2 {
+			if strings.ToUpper(args[i]) == "MATCH" {
+				pattern = args[i+1]
+			}
+		}
+		var keys []string
+		for key := range f.data {
+			if ok, _ := path.Match(pattern, key); ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		fmt.Fprint(w, "*2\r\n")
+		writeBulk(w, "0")
+		writeArray(w, keys)
+	case "SMEMBERS":
+		set, _ := f.data[args[1]].(map[string]bool)
+		members := make([]string, 0, len(set))
+		for m := range set {
+			members = append(members, m)
+		}
+		sort.Strings(members)
+		writeArray(w, members)
+	case "ZRANGE", "LRANGE":
+		list, _ := f.data[args[1]].([]string)
+		writeArray(w, list)
+	case "LREM":
+		list, _ := f.data[args[1]].([]string)
+		kept := list[:0:0]
+		for _, v := range list {
+			if v != args[3] {
+				kept = append(kept, v)
+			}
+		}
+		f.data[args[1]] = kept
+		writeInt(w, len(list)-len(kept))
+	case "HKEYS":
+		hash, _ := f.data[args[1]].(map[string]string)
+		fields := make([]string, 0, len(hash))
+		for k := range hash {
+			fields = append(fields, k)
+		}
+		sort.Strings(fields)
+		writeArray(w, fields)
+	case "HDEL":
+		hash, _ := f.data[args[1]].(map[string]string)
+		n := 0
+		for _, field := range args[2:] {
+			if _, ok := hash[field]; ok {
+				delete(hash, field)
+				n++
+			}
+		}
+		writeInt(w, n)
+	default:
+		fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, n)
+	for range n {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+func writeInt(w *bufio.Writer, n int) {
+	fmt.Fprintf(w, ":%d\r\n", n)
+}
+
+func writeBulk(w *bufio.Writer, s string) {
+	fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+}
+
+func writeArray(w *bufio.Writer, items []string) {
+	fmt.Fprintf(w, "*%d\r\n", len(items))
+	for _, item := range items {
+		writeBulk(w, item)
+	}
+}
@@ -49,9 +49,9 @@ func GetUserChat(ctx context.Context, rdb *redis.Client, key string) (string, er
 
 	return val, nil
 }
-func SetUserQueries(rdb *redis.Client, query string, key string, ctx context.Context) error {
+func SetUserQueries(rdb *redis.Client, query string, key string, ttl time.Duration, ctx context.Context) error {
 	res := rdb.LPush(ctx, key, query)
-	rdb.Expire(ctx, key, time.Hour*48)
+	rdb.Expire(ctx, key, ttl)
 	if res.Err() != nil {
 		return fmt.Errorf("failed to push value to redis: %v ", res.Err().Error())
 	}
@@ -63,11 +63,11 @@ func SetUserQueries(rdb *redis.Client, query string, key string, ctx context.Con
 	return nil
 }
 
-func SetUserChat(rdb *redis.Client, key string, val string, ctx context.Context) error {
-	_, err := rdb.Set(ctx, key, val, time.Hour*48).Result()
+func SetUserChat(rdb *redis.Client, key string, val string, ttl time.Duration, ctx context.Context) error {
+	_, err := rdb.Set(ctx, key, val, ttl).Result()
 
 	if err != nil {
-		return nil
+		return err
 	}
 	return nil
 }
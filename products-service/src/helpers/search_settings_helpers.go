@@ -0,0 +1,47 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// GetSearchSettings returns the org's search settings, falling back to
+// defaults when none have been stored.
+func GetSearchSettings(ctx context.Context, rdb *redis.Client, orgID string, defaults models.SearchSettings) (models.SearchSettings, error) {
+	val, err := rdb.Get(ctx, GetSearchSettingsKey(orgID)).Result()
+	if err == redis.Nil {
+		return defaults, nil
+	}
+	if err != nil {
+		return defaults, err
+	}
+
+	settings := defaults
+	if err := json.Unmarshal([]byte(val), &settings); err != nil {
+		return defaults, fmt.Errorf("failed to decode search settings: %v", err)
+	}
+	return settings, nil
+}
+
+func SetSearchSettings(ctx context.Context, rdb *redis.Client, orgID string, settings models.SearchSettings) error {
+	byt, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	if err := rdb.Set(ctx, GetSearchSettingsKey(orgID), byt, 0).Err(); err != nil {
+		return fmt.Errorf("failed to save search settings: %v", err)
+	}
+	return nil
+}
+
+func DeleteSearchSettings(ctx context.Context, rdb *redis.Client, orgID string) error {
+	return rdb.Del(ctx, GetSearchSettingsKey(orgID)).Err()
+}
+
+func GetSearchSettingsKey(orgID string) string {
+	return fmt.Sprintf("search_settings:%v", orgID)
+}
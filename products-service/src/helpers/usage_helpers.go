@@ -0,0 +1,157 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// usageTTL keeps daily usage aggregates for a little over a year.
+const usageTTL = time.Hour * 24 * 400
+
+const usageDateLayout = "2006-01-02"
+
+// RecordUsage adds a call's tokens to the org's daily aggregates, both by
+// task and model and by user.
+func RecordUsage(ctx context.Context, rdb *redis.Client, rec models.UsageRecord) error {
+	date := rec.Time.UTC().Format(usageDateLayout)
+	orgKey := GetUsageKey(rec.OrgID, date)
+	userKey := GetUserUsageKey(rec.OrgID, date)
+
+	pipe := rdb.TxPipeline()
+
+	field := rec.Task + "|" + rec.Model
+	pipe.HIncrBy(ctx, orgKey, field+"|requests", 1)
+	pipe.HIncrBy(ctx, orgKey, field+"|prompt", rec.PromptTokens)
+	pipe.HIncrBy(ctx, orgKey, field+"|completion", rec.CompletionTokens)
+	pipe.Expire(ctx, orgKey, usageTTL)
+
+	monthKey := GetMonthlyTokensKey(rec.OrgID, rec.Time.UTC())
+	pipe.IncrBy(ctx, monthKey, rec.PromptTokens+rec.CompletionTokens)
+	pipe.Expire(ctx, monthKey, time.Hour*24*62)
+
+	if rec.UserID != "" {
+		field := rec.UserID + "|" + rec.Model
+		pipe.HIncrBy(ctx, userKey, field+"|requests", 1)
+		pipe.HIncrBy(ctx, userKey, field+"|prompt", rec.PromptTokens)
+		pipe.HIncrBy(ctx, userKey, field+"|completion", rec.CompletionTokens)
+		pipe.Expire(ctx, userKey, usageTTL)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record usage: %v", err)
+	}
+	return nil
+}
+
+// GetDailyUsage returns the org's usage for a day grouped by task and model,
+// and grouped by user and model.
+func GetDailyUsage(ctx context.Context, rdb *redis.Client, orgID string, date string) ([]models.UsageLine, []models.UsageLine, error) {
+	byTask, err := rdb.HGetAll(ctx, GetUsageKey(orgID, date)).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byUser, err := rdb.HGetAll(ctx, GetUserUsageKey(orgID, date)).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	taskLines := usageLines(byTask, func(line *models.UsageLine, key string) { line.Task = key })
+	userLines := usageLines(byUser, func(line *models.UsageLine, key string) { line.UserID = key })
+	return taskLines, userLines, nil
+}
+
+// usageLines folds "<key>|<model>|<counter>" hash fields into lines.
+func usageLines(fields map[string]string, setKey func(*models.UsageLine, string)) []models.UsageLine {
+	lines := map[string]*models.UsageLine{}
+
+	for field, val := range fields {
+		i := strings.LastIndex(field, "|")
+		if i < 0 {
+			continue
+		}
+		group, counter := field[:i], field[i+1:]
+
+		j := strings.LastIndex(group, "|")
+		if j < 0 {
+			continue
+		}
+
+		line, ok := lines[group]
+		if !ok {
+			line = &models.UsageLine{Model: group[j+1:]}
+			setKey(line, group[:j])
+			lines[group] = line
+		}
+
+		var n int64
+		fmt.Sscan(val, &n)
+
+		switch counter {
+		case "requests":
+			line.Requests = n
+		case "prompt":
+			line.PromptTokens = n
+		case "completion":
+			line.CompletionTokens = n
+		}
+	}
+
+	keys := make([]string, 0, len(lines))
+	for k := range lines {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]models.UsageLine, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, *lines[k])
+	}
+	return out
+}
+
+// removeUserUsage drops the user's fields from every per-user usage
+// aggregate of the org. Org-level totals are kept as they are not personal.
+func removeUserUsage(ctx context.Context, rdb *redis.Client, orgID string, userID string) (int, error) {
+	removed := 0
+	prefix := userID + "|"
+
+	iter := rdb.Scan(ctx, 0, "llm_usage_users:"+escapeGlob(orgID)+":*", 100).Iterator()
+	for iter.Next(ctx) {
+		fields, err := rdb.HKeys(ctx, iter.Val()).Result()
+		if err != nil {
+			return removed, err
+		}
+
+		var userFields []string
+		for _, f := range fields {
+			if strings.HasPrefix(f, prefix) && strings.Count(f[len(prefix):], "|") == 1 {
+				userFields = append(userFields, f)
+			}
+		}
+		if len(userFields) == 0 {
+			continue
+		}
+
+		n, err := rdb.HDel(ctx, iter.Val(), userFields...).Result()
+		if err != nil {
+			return removed, err
+		}
+		removed += int(n)
+	}
+	return removed, iter.Err()
+}
+
+func GetUsageKey(orgID string, date string) string {
+	return fmt.Sprintf("llm_usage:%v:%v", orgID, date)
+}
+
+func GetUserUsageKey(orgID string, date string) string {
+	return fmt.Sprintf("llm_usage_users:%v:%v", orgID, date)
+}
@@ -0,0 +1,247 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/openai/openai-go/v3"
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// Every chat message costs a few tokens of framing on top of its content,
+// and the reply is primed with a few more.
+const (
+	tokensPerMessage = 3
+	tokensPerReply   = 3
+
+	// maxDescriptionRunes bounds product descriptions sent to the model.
+	maxDescriptionRunes = 400
+)
+
+// layoutProductFields are the product properties the layout prompt can use.
+// Everything else (SKU ids, stock counts, search text) is dropped.
+var layoutProductFields = map[string]bool{
+	"productId":                 true,
+	"name":                      true,
+	"brand":                     true,
+	"description":               true,
+	"minPrice":                  true,
+	"priceCurrency":             true,
+	"availability":              true,
+	"attr_1_attributeName":      true,
+	"attr_1_value":              true,
+	"attr_1_associateValueName": true,
+	"attr_1_image":              true,
+	"attr_1_onClickUrl":         true,
+	"attr_1_price":              true,
+	"attr_1_salePrice":          true,
+	"attr_1_availability":       true,
+	"attr_2_attributeName":      true,
+	"attr_2_value":              true,
+	"attr_2_associateValueName": true,
+	"attr_2_image":              true,
+	"attr_2_onClickUrl":         true,
+	"attr_2_price":              true,
+	"attr_2_salePrice":          true,
+	"attr_2_availability":       true,
+}
+
+// tokenCounter counts tokens locally with the model's BPE encoding. The
+// encodings are embedded in the binary, so nothing is downloaded at run time.
+// If one still fails to load, counts fall back to a characters-per-token
+// estimate.
+type tokenCounter struct {
+	once sync.Once
+	enc  *tiktoken.Tiktoken
+}
+
+var (
+	tokenCounters  sync.Map
+	useEmbeddedBpe sync.Once
+)
+
+func counterFor(model string, log *slog.Logger) *tokenCounter {
+	c, _ := tokenCounters.LoadOrStore(model, &tokenCounter{})
+	counter := c.(*tokenCounter)
+
+	counter.once.Do(func() {
+		enc, err := loadEncoding(model)
+		if err != nil {
+			log.Error("failed to load tokenizer, estimating token counts", "model", model, "err", err)
+			return
+		}
+		counter.enc = enc
+	})
+	return counter
+}
+
+// CheckTokenizer loads the encoding of model, so a missing tokenizer stops
+// startup instead of degrading every prompt budget to an estimate.
+func CheckTokenizer(model string) error {
+	if _, err := loadEncoding(model); err != nil {
+		return fmt.Errorf("unable to load tokenizer for %s.%v", model, err)
+	}
+	return nil
+}
+
+func loadEncoding(model string) (*tiktoken.Tiktoken, error) {
+	// The default loader would fetch encodings from the internet on first
+	// use, stalling the first answer or failing behind a firewall.
+	useEmbeddedBpe.Do(func() { tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader()) })
+
+	enc, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		// Newer models are not in the tokenizer's table yet; they all use
+		// o200k.
+		enc, err = tiktoken.GetEncoding(tiktoken.MODEL_O200K_BASE)
+	}
+	return enc, err
+}
+
+func (t *tokenCounter) count(text string) int {
+	if t.enc == nil {
+		return (len(text) + 3) / 4
+	}
+	return len(t.enc.EncodeOrdinary(text))
+}
+
+func (t *tokenCounter) message(text string) int {
+	return tokensPerMessage + t.count(text)
+}
+
+// promptContext is everything that may be sent for a single answer, in the
+// order it is dropped when the budget runs out: turns first (oldest first),
+// then the summary, then products (lowest ranked first). Query, layout,
+// reference and preferences are always sent.
+type promptContext struct {
+	Products    []models.ProductHit
+	Reference   string
+	Preferences string
+	Summary     string
+	Turns       []models.ChatTurn
+	Query       string
+}
+
+// buildMessages assembles the chat messages for model within budget prompt
+// tokens. It returns the products that made it into the prompt and the
+// estimated prompt tokens.
+func buildMessages(ctx context.Context, log *slog.Logger, model string, budget int, in promptContext) ([]openai.ChatCompletionMessageParamUnion, []models.ProductHit, int) {
+	counter := counterFor(model, log)
+
+	layoutMsg := RESPONSE_UI_COMPONENTS_AND_PROMPT
+	summaryMsg := fmt.Sprintf("This is previous chat summary \n %v", in.Summary)
+
+	used := tokensPerReply + counter.message(layoutMsg) + counter.message(in.Query)
+	if in.Reference != "" {
+		used += counter.message(in.Reference)
+	}
+	if in.Preferences != "" {
+		used += counter.message(in.Preferences)
+	}
+
+	// Products go in rank order until the budget is spent. Each one costs
+	// its own JSON plus a separator.
+	used += counter.message(productsMessage(nil))
+
+	kept := make([]models.ProductHit, 0, len(in.Products))
+	for _, p := range in.Products {
+		byt, _ := json.Marshal(layoutProducts([]models.ProductHit{p})[0])
+		cost := counter.count(string(byt)) + 1
+		if used+cost > budget {
+			break
+		}
+		used += cost
+		kept = append(kept, p)
+	}
+	productsMsg := productsMessage(kept)
+
+	includeSummary := in.Summary != "" && used+counter.message(summaryMsg) <= budget
+	if includeSummary {
+		used += counter.message(summaryMsg)
+	}
+
+	// Newest turns are the most useful, so keep from the end.
+	firstTurn := len(in.Turns)
+	for i := len(in.Turns) - 1; i >= 0; i-- {
+		cost := counter.message(in.Turns[i].Query) + counter.message(in.Turns[i].Response)
+		if used+cost > budget {
+			break
+		}
+		used += cost
+		firstTurn = i
+	}
+
+	droppedProducts := len(in.Products) - len(kept)
+	droppedTurns := firstTurn
+	if droppedProducts > 0 || droppedTurns > 0 || (in.Summary != "" && !includeSummary) || used > budget {
+		log.InfoContext(ctx, "context window trimmed", "model", model, "used", used, "budget", budget,
+			"droppedProducts", droppedProducts, "droppedTurns", droppedTurns, "droppedSummary", in.Summary != "" && !includeSummary)
+	}
+
+	messages := []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage(productsMsg),
+	}
+	if in.Reference != "" {
+		messages = append(messages, openai.SystemMessage(in.Reference))
+	}
+	if in.Preferences != "" {
+		messages = append(messages, openai.SystemMessage(in.Preferences))
+	}
+	messages = append(messages, openai.DeveloperMessage(layoutMsg))
+	if includeSummary {
+		messages = append(messages, openai.AssistantMessage(summaryMsg))
+	}
+	for _, turn := range in.Turns[firstTurn:] {
+		messages = append(messages,
+			openai.UserMessage(turn.Query),
+			openai.AssistantMessage(turn.Response),
+		)
+	}
+	messages = append(messages, openai.UserMessage(in.Query))
+
+	return messages, kept, used
+}
+
+func productsMessage(products []models.ProductHit) string {
+	byt, _ := json.Marshal(layoutProducts(products))
+	return fmt.Sprintf("These are the products you can recommend \n %v", string(byt))
+}
+
+// layoutProducts keeps only the fields the layout needs and shortens
+// descriptions.
+func layoutProducts(hits []models.ProductHit) []map[string]any {
+	products := make([]map[string]any, 0, len(hits))
+	for _, h := range hits {
+		product := make(map[string]any, len(layoutProductFields))
+		for k, v := range h.Properties {
+			if !layoutProductFields[k] || v == nil {
+				continue
+			}
+			if s, ok := v.(string); ok {
+				if s == "" {
+					continue
+				}
+				if k == "description" {
+					v = truncateRunes(s, maxDescriptionRunes)
+				}
+			}
+			product[k] = v
+		}
+		products = append(products, product)
+	}
+	return products
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:n])) + "…"
+}
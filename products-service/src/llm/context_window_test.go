@@ -0,0 +1,38 @@
+package llm
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestCounterUsesEmbeddedEncoding(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		model string
+		text  string
+		want  int
+	}{
+		{model: "gpt-4o", text: "hello world", want: 2},
+		{model: "gpt-4", text: "hello world", want: 2},
+		// Models the tokenizer does not know use o200k.
+		{model: "gpt-unreleased", text: "tokenization", want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			if err := CheckTokenizer(tt.model); err != nil {
+				t.Fatalf("CheckTokenizer: %v", err)
+			}
+
+			counter := counterFor(tt.model, log)
+			if counter.enc == nil {
+				t.Fatal("encoding not loaded, counts are estimated")
+			}
+			if got := counter.count(tt.text); got != tt.want {
+				t.Errorf("count(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -4,13 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/Adityadangi14/ecomm_ai/config"
+	"github.com/Adityadangi14/ecomm_ai/pkg/tracing"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/merch"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/metrics"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/repository"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/rerank"
 	"github.com/Adityadangi14/ecomm_ai/utils"
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
@@ -19,29 +25,55 @@ import (
 
 type Aiclient interface {
 	ImageByteToText(context.Context, string) (string, error)
-	SummerizePastQueris(string) string
-	GetSementicText(map[string]any) (string, error)
-	ProcessProduct(prod models.Product) (map[string]any, error)
-	GetAiQueryReponse(params models.AiQueryParams, msg chan models.MessageChanStruct)
-	SummerizePastChats(pastSummary string, query string) string
+	SummerizePastQueris(context.Context, string) string
+	GetSementicText(context.Context, map[string]any) (string, error)
+	ProcessProduct(ctx context.Context, prod models.Product) (map[string]any, error)
+	GetAiQueryReponse(ctx context.Context, params models.AiQueryParams, msg chan models.MessageChanStruct)
+	SummerizePastChats(ctx context.Context, pastSummary string, query string) string
+	RankProducts(ctx context.Context, query string, products []string) ([]int, error)
+	ExtractPreferences(ctx context.Context, current models.ShopperPreferences, query string) (models.ShopperPreferences, error)
+	Ping(ctx context.Context) error
 }
 
 type aiclient struct {
 	LlmClient   *openai.Client
 	rbd         *redis.Client
 	productRepo repository.ProductRepository
+	cfg         config.LLMConfig
+	runtime     *config.Runtime
+	log         *slog.Logger
 }
 
-func NewAiClient(rdb *redis.Client, productRepo repository.ProductRepository) Aiclient {
+func NewAiClient(rdb *redis.Client, productRepo repository.ProductRepository, cfg config.LLMConfig, runtime *config.Runtime, log *slog.Logger) Aiclient {
 	key := os.Getenv("OPENAI_KEY")
 	client := openai.NewClient(option.WithAPIKey(key))
-	return &aiclient{LlmClient: &client, rbd: rdb, productRepo: productRepo}
+
+	if cfg.DefaultContextBudget <= 0 {
+		cfg.DefaultContextBudget = 16000
+	}
+
+	return &aiclient{LlmClient: &client, rbd: rdb, productRepo: productRepo, cfg: cfg, runtime: runtime, log: log}
+}
+
+// models returns the currently configured model of each task. Calls read it
+// once so a reload never switches models mid-call.
+func (a *aiclient) models() config.ModelConfig {
+	return a.runtime.Current().Models
+}
+
+// Ping checks the provider is reachable and knows the chat model, without
+// retries and without spending tokens.
+func (a *aiclient) Ping(ctx context.Context) error {
+	_, err := a.LlmClient.Models.Get(ctx, a.models().ChatModel, option.WithMaxRetries(0))
+	return err
 }
 
 func (a *aiclient) ImageByteToText(ctx context.Context, url string) (string, error) {
 
+	model := a.models().EnrichmentModel
+
 	params := openai.ChatCompletionNewParams{
-		Model: openai.ChatModel("gpt-4o-mini"),
+		Model: model,
 		Messages: []openai.ChatCompletionMessageParamUnion{
 			{
 
@@ -65,14 +97,17 @@ func (a *aiclient) ImageByteToText(ctx context.Context, url string) (string, err
 	// Retry logic
 	maxRetries := 3
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		resp, err = a.LlmClient.Chat.Completions.New(ctx, params)
+		resp, err = a.complete(ctx, models.UsageTaskImageDescription, params)
 		if err == nil {
 			break
 		}
+		if attempt < maxRetries {
+			metrics.LLMRetries.WithLabelValues(models.UsageTaskImageDescription, string(model)).Inc()
+		}
 
 		// Exponential backoff sleep
 		wait := time.Duration(attempt*attempt) * time.Second
-		log.Printf("Retrying OpenAI request after error (attempt %d/%d): %v. Waiting %v...", attempt, maxRetries, err, wait)
+		a.log.WarnContext(ctx, "retrying image description", "attempt", attempt, "maxRetries", maxRetries, "wait", wait, "err", err)
 		time.Sleep(wait)
 	}
 
@@ -80,6 +115,8 @@ func (a *aiclient) ImageByteToText(ctx context.Context, url string) (string, err
 		return "", fmt.Errorf("failed after %d attempts: %w", maxRetries, err)
 	}
 
+	a.recordUsage(ctx, models.UsageTaskImageDescription, model, resp.Usage)
+
 	if len(resp.Choices) == 0 {
 		return "", fmt.Errorf("no choices returned from OpenAI")
 	}
@@ -87,12 +124,14 @@ func (a *aiclient) ImageByteToText(ctx context.Context, url string) (string, err
 	return resp.Choices[0].Message.Content, nil
 }
 
-func (a *aiclient) SummerizePastQueris(query string) string {
+func (a *aiclient) SummerizePastQueris(ctx context.Context, query string) string {
+	model := a.models().SummaryModel
 
-	resp, err := a.LlmClient.Chat.Completions.New(
-		context.Background(),
+	resp, err := a.complete(
+		ctx,
+		models.UsageTaskQuerySummary,
 		openai.ChatCompletionNewParams{
-			Model: openai.ChatModelGPT4Turbo,
+			Model: model,
 			Messages: []openai.ChatCompletionMessageParamUnion{
 				openai.SystemMessage("Using only the information provided, condense the user's decayed search queries into a single, concise sentence that captures the overall intent and topics, strictly avoiding opinions, assumptions, extra details, or creative additions. Preserve the meaning according to the weight (higher-weight queries influence the summary more), and produce only one clear summary line as output."),
 				openai.UserMessage(query),
@@ -102,10 +141,12 @@ func (a *aiclient) SummerizePastQueris(query string) string {
 	)
 
 	if err != nil {
-		log.Println("OpenAI summary error:", err)
+		a.log.ErrorContext(ctx, "query summary failed", "err", err)
 		return ""
 	}
 
+	a.recordUsage(ctx, models.UsageTaskQuerySummary, model, resp.Usage)
+
 	if len(resp.Choices) == 0 {
 		return ""
 	}
@@ -113,12 +154,14 @@ func (a *aiclient) SummerizePastQueris(query string) string {
 	return resp.Choices[0].Message.Content
 }
 
-func (a *aiclient) SummerizePastChats(pastSummary string, query string) string {
+func (a *aiclient) SummerizePastChats(ctx context.Context, pastSummary string, query string) string {
+	model := a.models().SummaryModel
 
-	resp, err := a.LlmClient.Chat.Completions.New(
-		context.Background(),
+	resp, err := a.complete(
+		ctx,
+		models.UsageTaskChatSummary,
 		openai.ChatCompletionNewParams{
-			Model: openai.ChatModelGPT4Turbo,
+			Model: model,
 			Messages: []openai.ChatCompletionMessageParamUnion{
 				openai.UserMessage("Previous Summary: " + pastSummary),
 
@@ -133,10 +176,12 @@ func (a *aiclient) SummerizePastChats(pastSummary string, query string) string {
 	)
 
 	if err != nil {
-		log.Println("OpenAI summary error:", err)
+		a.log.ErrorContext(ctx, "chat summary failed", "err", err)
 		return ""
 	}
 
+	a.recordUsage(ctx, models.UsageTaskChatSummary, model, resp.Usage)
+
 	if len(resp.Choices) == 0 {
 		return ""
 	}
@@ -144,8 +189,9 @@ func (a *aiclient) SummerizePastChats(pastSummary string, query string) string {
 	return resp.Choices[0].Message.Content
 }
 
-func (a *aiclient) GetSementicText(prod map[string]any) (string, error) {
-	ctx := context.Background()
+func (a *aiclient) GetSementicText(ctx context.Context, prod map[string]any) (string, error) {
+	orgID, _ := prod["orgId"].(string)
+	ctx = WithUsageScope(ctx, orgID, "")
 
 	// Convert product map to JSON string
 	jsonBytes, err := json.Marshal(prod)
@@ -160,9 +206,11 @@ func (a *aiclient) GetSementicText(prod map[string]any) (string, error) {
 		string(jsonBytes),
 	)
 
+	model := a.models().EnrichmentModel
+
 	// Construct chat completion parameters
 	params := openai.ChatCompletionNewParams{
-		Model: openai.ChatModelGPT4oMini,
+		Model: model,
 		Messages: []openai.ChatCompletionMessageParamUnion{
 			openai.UserMessage(finalPrompt),
 		},
@@ -172,16 +220,17 @@ func (a *aiclient) GetSementicText(prod map[string]any) (string, error) {
 	maxRetries := 3
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		resp, err = a.LlmClient.Chat.Completions.New(ctx, params)
+		resp, err = a.complete(ctx, models.UsageTaskSemanticText, params)
 		if err == nil {
 			break
 		}
+		if attempt < maxRetries {
+			metrics.LLMRetries.WithLabelValues(models.UsageTaskSemanticText, string(model)).Inc()
+		}
 
 		// Backoff strategy: 1s, 2s, 4s (or use square: 1,4,9)
 		wait := time.Duration(attempt*attempt) * time.Second
-		log.Printf("Retrying semantic text request (attempt %d/%d): %v. Waiting %v...",
-			attempt, maxRetries, err, wait,
-		)
+		a.log.WarnContext(ctx, "retrying semantic text", "attempt", attempt, "maxRetries", maxRetries, "wait", wait, "err", err)
 		time.Sleep(wait)
 	}
 
@@ -189,6 +238,8 @@ func (a *aiclient) GetSementicText(prod map[string]any) (string, error) {
 		return "", fmt.Errorf("GetSementicText failed after %d attempts: %w", maxRetries, err)
 	}
 
+	a.recordUsage(ctx, models.UsageTaskSemanticText, model, resp.Usage)
+
 	if len(resp.Choices) == 0 {
 		return "", fmt.Errorf("no choices returned")
 	}
@@ -196,8 +247,8 @@ func (a *aiclient) GetSementicText(prod map[string]any) (string, error) {
 	return resp.Choices[0].Message.Content, nil
 }
 
-func (a *aiclient) ProcessProduct(prod models.Product) (map[string]any, error) {
-	ctx := context.Background()
+func (a *aiclient) ProcessProduct(ctx context.Context, prod models.Product) (map[string]any, error) {
+	ctx = WithUsageScope(ctx, prod.OrgID, "")
 	// Convert to flat map
 	prodMap := prod.ToFlatMap()
 
@@ -215,7 +266,7 @@ func (a *aiclient) ProcessProduct(prod models.Product) (map[string]any, error) {
 
 	prodMap["product_image_description"] = imageDesc
 
-	semanticText, err := a.GetSementicText(prodMap)
+	semanticText, err := a.GetSementicText(ctx, prodMap)
 	if err != nil {
 
 		return nil, fmt.Errorf("semantic text generation failed: %w", err)
@@ -226,65 +277,386 @@ func (a *aiclient) ProcessProduct(prod models.Product) (map[string]any, error) {
 	return prodMap, nil
 }
 
-func (a *aiclient) GetAiQueryReponse(params models.AiQueryParams, msg chan models.MessageChanStruct) {
+func (a *aiclient) GetAiQueryReponse(ctx context.Context, params models.AiQueryParams, msg chan models.MessageChanStruct) {
 
+	ctx = requestContext(ctx, params)
 	key := helpers.GetUserQueriesKey(params)
 
-	err := helpers.SetUserQueries(a.rbd, params.Query, key, context.Background())
-
+	retention, err := helpers.GetRetentionPolicy(ctx, a.rbd, params.OrgID)
 	if err != nil {
-		fmt.Println(err)
+		a.log.WarnContext(ctx, "failed to load retention policy, using defaults", "err", err)
 	}
 
-	res, err := helpers.GetQueriesWithDecay(context.Background(), a.rbd, key)
+	// Nothing is stored for a session erasure cannot find through its index.
+	if err := helpers.TrackUserSession(ctx, a.rbd, params, retention); err != nil {
+		a.log.ErrorContext(ctx, "failed to track session for erasure", "err", err)
+		msg <- models.MessageChanStruct{Err: fmt.Errorf("unable to start the session, please retry")}
+		close(msg)
+		return
+	}
 
-	querySummary := a.SummerizePastQueris(res)
+	err = helpers.SetUserQueries(a.rbd, params.Query, key, retention.QueriesTTL(), ctx)
 
-	fmt.Println("querysummary", querySummary)
+	if err != nil {
+		a.log.ErrorContext(ctx, "failed to store query", "err", err)
+	}
 
+	// Preferences stated in this turn are extracted once the answer is done
+	// and apply from the next turn, so extraction never delays the answer.
+	prefs, err := helpers.GetUserPreferences(ctx, a.rbd, params.UserID, params.OrgID)
 	if err != nil {
-		fmt.Println(err)
+		a.log.WarnContext(ctx, "failed to load shopper preferences", "err", err)
 	}
 
-	products, err := a.productRepo.NearSearchProducts(context.Background(), querySummary, params.OrgID)
+	res, err := helpers.GetQueriesWithDecay(ctx, a.rbd, key)
 
-	fmt.Println("products", products)
+	if err != nil {
+		a.log.ErrorContext(ctx, "failed to load past queries", "err", err)
+	}
+
+	querySummary := a.SummerizePastQueris(ctx, res)
+
+	products, err := a.retrieveProducts(ctx, querySummary, params, prefs)
 
 	if err != nil {
-		fmt.Println("unable to do near search", err)
+		a.log.ErrorContext(ctx, "unable to do near search", "err", err)
 	}
 
-	byt, err := json.Marshal(products)
+	a.log.DebugContext(ctx, "retrieved products", "count", len(products), "productIds", productIDs(products))
+
+	referenceMessage := ""
+	if params.ProductID != "" {
+		reference, similar, err := a.similarToReference(ctx, params, prefs)
+		if err != nil {
+			a.log.ErrorContext(ctx, "unable to get similar products", "productId", params.ProductID, "err", err)
+		} else {
+			products = similar
+			refByt, _ := json.Marshal(reference.Properties)
+			referenceMessage = fmt.Sprintf("The user is referring to this product from an earlier turn. Recommend alternatives relative to it \n %v", string(refByt))
+		}
+	}
 
-	chatRes, err := helpers.GetUserChat(context.Background(), a.rbd, helpers.GetUserChatKey(params))
+	products = a.applyMerchandising(ctx, params, products)
+	// Pins can bring back products the search left out.
+	products = models.RankByAvailability(products, true)
 
-	fmt.Println("chatSummary", chatRes)
+	chatRes, err := helpers.GetUserChat(ctx, a.rbd, helpers.GetUserChatKey(params))
+	if err != nil && err != redis.Nil {
+		a.log.WarnContext(ctx, "failed to load chat summary", "err", err)
+	}
 
-	stream := a.LlmClient.Chat.Completions.NewStreaming(context.Background(), openai.ChatCompletionNewParams{
-		Model: openai.ChatModelGPT4_1,
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(fmt.Sprintf("These are the products you can recommend \n %v", string(byt))),
-			openai.DeveloperMessage(RESPONSE_UI_COMPONENTS_AND_PROMPT),
-			openai.AssistantMessage(fmt.Sprintf("This is previous chat summary \n %v", chatRes)),
-			openai.UserMessage(params.Query),
+	preferencesMessage := ""
+	if !prefs.IsEmpty() {
+		prefsByt, _ := json.Marshal(prefs)
+		preferencesMessage = fmt.Sprintf("These are the shopper's known preferences. Respect sizes, budget and exclusions, and favour liked brands and colors \n %v", string(prefsByt))
+	}
+
+	model := a.models().ChatModel
+
+	messages, products, promptTokens := buildMessages(ctx, a.log, model, a.cfg.PromptBudget(model), promptContext{
+		Products:    products,
+		Reference:   referenceMessage,
+		Preferences: preferencesMessage,
+		Summary:     chatRes,
+		Turns:       a.recentTurns(ctx, params),
+		Query:       params.Query,
+	})
+
+	streamCtx, span := startLLMSpan(ctx, models.UsageTaskResponse, model)
+	defer span.End()
+
+	start := time.Now()
+	stream := a.LlmClient.Chat.Completions.NewStreaming(streamCtx, openai.ChatCompletionNewParams{
+		Model:    model,
+		Messages: messages,
+		StreamOptions: openai.ChatCompletionStreamOptionsParam{
+			IncludeUsage: openai.Bool(true),
 		},
 	})
 
 	defer stream.Close()
 	defer close(msg)
 
+	msg <- models.MessageChanStruct{Meta: &models.ResponseMeta{Model: string(model), ProductIDs: productIDs(products)}}
+
 	if stream.Err() != nil {
-		fmt.Println("Stream error:", stream.Err())
+		a.log.ErrorContext(ctx, "stream error", "err", stream.Err())
 	}
 
+	// With include_usage the last chunk carries the usage of the whole
+	// response and no choices.
+	var usage openai.CompletionUsage
+	var answer strings.Builder
 	for stream.Next() {
 		event := stream.Current()
+		if event.Usage.TotalTokens > 0 {
+			usage = event.Usage
+		}
 		if len(event.Choices) > 0 && event.Choices[0].Delta.Content != "" {
+			answer.WriteString(event.Choices[0].Delta.Content)
 			msg <- models.MessageChanStruct{Chunk: event.Choices[0].Delta.Content}
 		}
 	}
 
+	// A stream cut short, e.g. by the shopper leaving, never gets the usage
+	// chunk but is still billed, so its usage is estimated.
+	if usage.TotalTokens == 0 && (ctx.Err() != nil || answer.Len() > 0) {
+		completionTokens := counterFor(model, a.log).count(answer.String())
+		usage = openai.CompletionUsage{
+			PromptTokens:     int64(promptTokens),
+			CompletionTokens: int64(completionTokens),
+			TotalTokens:      int64(promptTokens + completionTokens),
+		}
+		a.log.InfoContext(ctx, "stream ended without usage, recording an estimate", "promptTokens", usage.PromptTokens, "completionTokens", usage.CompletionTokens)
+	}
+
+	metrics.ObserveLLM(models.UsageTaskResponse, string(model), start, stream.Err())
+	tracing.Fail(span, stream.Err())
+	setUsage(span, usage)
+
+	if usage.TotalTokens > 0 {
+		a.recordUsage(ctx, models.UsageTaskResponse, model, usage)
+	}
+
 	if err := stream.Err(); err != nil {
 		msg <- models.MessageChanStruct{Err: fmt.Errorf("%s", fmt.Sprintf("Stream error: %v", err))}
 	}
+
+	go a.updatePreferences(context.WithoutCancel(ctx), params, retention)
+}
+
+// similarToReference anchors recommendations on the product the user
+// referenced instead of the hybrid search results.
+func (a *aiclient) similarToReference(ctx context.Context, params models.AiQueryParams, prefs models.ShopperPreferences) (*models.ProductHit, []models.ProductHit, error) {
+	reference, err := a.productRepo.GetProduct(ctx, params.OrgID, params.ProductID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hits, err := a.productRepo.SimilarProducts(ctx, models.SimilarParams{
+		SearchParams: prefs.ApplyToSearch(models.SearchParams{OrgID: params.OrgID, InStockOnly: true}),
+		ProductID:    params.ProductID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reference, hits, nil
+}
+
+// retrieveProducts runs hybrid search narrowed by the shopper's hard
+// preferences and, when the org has a reranker configured, reranks a wider
+// candidate set down to the search limit.
+func (a *aiclient) retrieveProducts(ctx context.Context, querySummary string, params models.AiQueryParams, prefs models.ShopperPreferences) ([]models.ProductHit, error) {
+	settings, err := helpers.GetSearchSettings(ctx, a.rbd, params.OrgID, models.DefaultSearchSettings(a.runtime.Current().Search))
+	if err != nil {
+		a.log.WarnContext(ctx, "failed to load search settings, using defaults", "err", err)
+	}
+
+	reranker := rerank.New(settings.Reranker, a)
+
+	// The assistant should only recommend what shoppers can buy.
+	search := models.SearchParams{OrgID: params.OrgID, Limit: settings.Limit, InStockOnly: true}
+	if reranker != nil {
+		search.Limit = settings.RerankCandidates
+	}
+
+	candidates, err := a.productRepo.NearSearchProducts(ctx, querySummary, prefs.ApplyToSearch(search))
+	if err != nil {
+		return nil, err
+	}
+
+	// Preferences can be stale or too strict; an empty shelf is worse than
+	// results that ignore them.
+	if len(candidates) == 0 && !prefs.IsEmpty() {
+		a.log.InfoContext(ctx, "no products match shopper preferences, retrying without them")
+		candidates, err = a.productRepo.NearSearchProducts(ctx, querySummary, search)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if reranker == nil {
+		return candidates, nil
+	}
+
+	reranked, err := reranker.Rerank(ctx, params.Query, candidates, settings.Limit)
+	if err != nil {
+		a.log.WarnContext(ctx, "rerank failed, using retrieval order", "reranker", settings.Reranker, "err", err)
+		if len(candidates) > settings.Limit {
+			candidates = candidates[:settings.Limit]
+		}
+		return candidates, nil
+	}
+
+	if settings.RerankDebug {
+		a.log.InfoContext(ctx, "rerank debug", "reranker", settings.Reranker, "table", rerank.DebugTable(reranked))
+	}
+
+	return reranked, nil
+}
+
+func (a *aiclient) RankProducts(ctx context.Context, query string, products []string) ([]int, error) {
+	var builder strings.Builder
+	builder.WriteString("Query: " + query + "\n\nCandidates:\n")
+	for i, p := range products {
+		builder.WriteString(fmt.Sprintf("%d. %s\n", i, p))
+	}
+
+	model := a.models().UtilityModel
+
+	resp, err := a.complete(ctx, models.UsageTaskRerank, openai.ChatCompletionNewParams{
+		Model: model,
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(RERANK_PRODUCTS_PROMPT),
+			openai.UserMessage(builder.String()),
+		},
+		Temperature: openai.Float(0),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.recordUsage(ctx, models.UsageTaskRerank, model, resp.Usage)
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices returned")
+	}
+
+	var order []int
+	content := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if err := json.Unmarshal([]byte(content), &order); err != nil {
+		return nil, fmt.Errorf("invalid rerank output %q: %w", content, err)
+	}
+
+	return order, nil
+}
+
+func (a *aiclient) ExtractPreferences(ctx context.Context, current models.ShopperPreferences, query string) (models.ShopperPreferences, error) {
+	currentByt, err := json.Marshal(current)
+	if err != nil {
+		return current, err
+	}
+
+	model := a.models().UtilityModel
+
+	resp, err := a.complete(ctx, models.UsageTaskPreferences, openai.ChatCompletionNewParams{
+		Model: model,
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(EXTRACT_PREFERENCES_PROMPT),
+			openai.UserMessage(fmt.Sprintf("Current profile: %s\n\nLatest message: %s", string(currentByt), query)),
+		},
+		Temperature: openai.Float(0),
+	})
+	if err != nil {
+		return current, err
+	}
+
+	a.recordUsage(ctx, models.UsageTaskPreferences, model, resp.Usage)
+
+	if len(resp.Choices) == 0 {
+		return current, fmt.Errorf("no choices returned")
+	}
+
+	var updated models.ShopperPreferences
+	content := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if err := json.Unmarshal([]byte(content), &updated); err != nil {
+		return current, fmt.Errorf("invalid preferences output %q: %w", content, err)
+	}
+
+	if err := updated.Validate(); err != nil {
+		return current, fmt.Errorf("invalid preferences output %q: %w", content, err)
+	}
+
+	return updated, nil
+}
+
+// updatePreferences folds the current turn into the shopper's stored
+// preferences. On any failure the stored profile is left as it was.
+func (a *aiclient) updatePreferences(ctx context.Context, params models.AiQueryParams, retention models.RetentionPolicy) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	prefs, err := helpers.GetUserPreferences(ctx, a.rbd, params.UserID, params.OrgID)
+	if err != nil {
+		a.log.WarnContext(ctx, "failed to load shopper preferences", "err", err)
+		return
+	}
+
+	updated, err := a.ExtractPreferences(ctx, prefs, params.Query)
+	if err != nil {
+		a.log.WarnContext(ctx, "failed to extract shopper preferences", "err", err)
+		return
+	}
+
+	if err := helpers.SetUserPreferences(ctx, a.rbd, params.UserID, params.OrgID, updated, retention.HistoryTTL()); err != nil {
+		a.log.ErrorContext(ctx, "failed to save shopper preferences", "err", err)
+	}
+}
+
+// applyMerchandising runs the org's merchandising rules over the products
+// about to be sent to the LLM and records which rules fired.
+func (a *aiclient) applyMerchandising(ctx context.Context, params models.AiQueryParams, products []models.ProductHit) []models.ProductHit {
+	rules, err := helpers.GetMerchRules(ctx, a.rbd, params.OrgID)
+	if err != nil {
+		a.log.WarnContext(ctx, "failed to load merchandising rules", "err", err)
+		return products
+	}
+	if len(rules) == 0 {
+		return products
+	}
+
+	products, fired := merch.Apply(params.Query, products, rules, func(productID string) (*models.ProductHit, error) {
+		hit, err := a.productRepo.GetProduct(ctx, params.OrgID, productID)
+		if err != nil {
+			a.log.WarnContext(ctx, "failed to fetch pinned product", "productId", productID, "err", err)
+		}
+		return hit, err
+	})
+
+	if len(fired) > 0 {
+		err := helpers.PushMerchAudit(ctx, a.rbd, params.OrgID, models.MerchAudit{
+			RequestID: params.RequestID,
+			UserID:    params.UserID,
+			Query:     params.Query,
+			Fired:     fired,
+			Time:      time.Now(),
+		})
+		if err != nil {
+			a.log.ErrorContext(ctx, "failed to record merchandising audit", "err", err)
+		}
+	}
+
+	return products
+}
+
+func productIDs(hits []models.ProductHit) []string {
+	ids := make([]string, 0, len(hits))
+	for _, h := range hits {
+		if id, ok := h.Properties["productId"].(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// recentTurns returns the latest answered turns of the session, oldest first.
+func (a *aiclient) recentTurns(ctx context.Context, params models.AiQueryParams) []models.ChatTurn {
+	if a.cfg.ContextRawTurns <= 0 {
+		return nil
+	}
+
+	turns, err := helpers.GetChatTurns(ctx, a.rbd, params, -a.cfg.ContextRawTurns, a.cfg.ContextRawTurns)
+	if err != nil {
+		a.log.WarnContext(ctx, "failed to load recent turns", "err", err)
+		return nil
+	}
+
+	answered := turns[:0]
+	for _, t := range turns {
+		if t.Error == "" && t.Response != "" {
+			answered = append(answered, t)
+		}
+	}
+	return answered
 }
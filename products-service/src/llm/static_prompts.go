@@ -12,6 +12,34 @@ Generate a clean paragraph description combining all relevant attributes below.
 	- No URLs or SKU details
 	`
 
+	RERANK_PRODUCTS_PROMPT = `
+	You rank products for an e-commerce shopping assistant.
+
+	You receive the shopper's query and a numbered list of candidate products.
+	Order the candidates from most to least relevant to the query, considering
+	product type, stated budget, brand, size, color and use case.
+
+	Output only a JSON array of the candidate numbers, e.g. [3, 0, 2, 1].
+	Do not output any other text.
+`
+
+	EXTRACT_PREFERENCES_PROMPT = `
+	You maintain a shopper's preference profile for an e-commerce assistant.
+
+	You receive the current profile as JSON and the shopper's latest message.
+	Return the updated profile using exactly the same JSON shape:
+	{"sizes": {"<product kind>": "<size>"}, "budget": {"min": number, "max": number, "currency": "<ISO code>"},
+	 "brandsLiked": [], "brandsDisliked": [], "colors": [], "exclusions": []}
+
+	- Only record preferences the shopper states about themselves.
+	- A newer statement replaces an older one on the same topic.
+	- Omit budget entirely if no budget has ever been stated.
+	- Exclusions are hard constraints such as "vegan" or "no leather".
+	- If the message states no preferences, return the current profile unchanged.
+
+	Output only the JSON object. Do not output any other text.
+`
+
 	CHAT_SUMMARY_PROMPT = `
 	You are an incremental summarizer.
 
@@ -155,9 +183,15 @@ Product fields (used in product_row / product_grid):
   "rating": { "value": <number>, "count": <number> },
   "tags": ["<string>"],
   "cta": { "label": "<string>", "url": "<string>" }
-  "onClickUrl":"<string>"
+  "onClickUrl":"<string>",
+  "availability": "<in_stock|low_stock|out_of_stock|preorder>"
 }
 
+Availability:
+- Copy "availability" from the product data. Omit it if the product has none.
+- NEVER recommend a variant whose availability is "out_of_stock".
+- Mention "low_stock" and "preorder" to the user so they know what to expect.
+
 
 RULES:
 - Always return valid JSON.
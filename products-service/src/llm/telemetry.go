@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"context"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/pkg/tracing"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/metrics"
+	"github.com/openai/openai-go/v3"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// complete sends one chat completion request for task, recording its span
+// and metrics.
+func (a *aiclient) complete(ctx context.Context, task string, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
+	ctx, span := startLLMSpan(ctx, task, params.Model)
+	defer span.End()
+
+	start := time.Now()
+	resp, err := a.LlmClient.Chat.Completions.New(ctx, params)
+	metrics.ObserveLLM(task, string(params.Model), start, err)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	setUsage(span, resp.Usage)
+	return resp, nil
+}
+
+func startLLMSpan(ctx context.Context, task string, model openai.ChatModel) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "openai "+task,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.GenAISystemOpenai,
+			semconv.GenAIOperationNameChat,
+			semconv.GenAIRequestModel(string(model)),
+			attribute.String("llm.task", task),
+		),
+	)
+}
+
+func setUsage(span trace.Span, usage openai.CompletionUsage) {
+	span.SetAttributes(
+		semconv.GenAIUsageInputTokens(int(usage.PromptTokens)),
+		semconv.GenAIUsageOutputTokens(int(usage.CompletionTokens)),
+	)
+}
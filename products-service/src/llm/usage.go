@@ -0,0 +1,59 @@
+package llm
+
+import (
+	"context"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/pkg/logger"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/openai/openai-go/v3"
+)
+
+type usageScopeKey struct{}
+
+type usageScope struct {
+	orgID  string
+	userID string
+}
+
+// WithUsageScope attributes the token usage of LLM calls made with ctx to
+// the org and user.
+func WithUsageScope(ctx context.Context, orgID string, userID string) context.Context {
+	return context.WithValue(ctx, usageScopeKey{}, usageScope{orgID: orgID, userID: userID})
+}
+
+// requestContext adds the usage scope and log attributes of a chat request
+// to ctx.
+func requestContext(ctx context.Context, params models.AiQueryParams) context.Context {
+	ctx = WithUsageScope(ctx, params.OrgID, params.UserID)
+	return logger.With(ctx, logger.RequestIDKey, params.RequestID, logger.OrgIDKey, params.OrgID)
+}
+
+// recordUsage stores the usage of one call. Calls without an org in ctx are
+// only logged.
+func (a *aiclient) recordUsage(ctx context.Context, task string, model openai.ChatModel, usage openai.CompletionUsage) {
+	scope, _ := ctx.Value(usageScopeKey{}).(usageScope)
+	if scope.orgID == "" {
+		a.log.WarnContext(ctx, "llm usage without org", "task", task, "model", model,
+			"promptTokens", usage.PromptTokens, "completionTokens", usage.CompletionTokens)
+		return
+	}
+
+	// The caller's context may already be cancelled once a stream ends.
+	recordCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	err := helpers.RecordUsage(recordCtx, a.rbd, models.UsageRecord{
+		OrgID:            scope.orgID,
+		UserID:           scope.userID,
+		Task:             task,
+		Model:            string(model),
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Time:             time.Now(),
+	})
+	if err != nil {
+		a.log.ErrorContext(ctx, "failed to record llm usage", "task", task, "err", err)
+	}
+}
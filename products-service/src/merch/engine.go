@@ -0,0 +1,249 @@
+package merch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+)
+
+// PinnedFetcher loads a pinned product that was not among the search results.
+// Pins whose product cannot be fetched are skipped.
+type PinnedFetcher func(productID string) (*models.ProductHit, error)
+
+// Apply runs an org's rules against the search results for a query. Blocks
+// are applied first, then boosts and buries reorder by score, and pins are
+// placed last so they always land in their slot. The result never grows past
+// the original result count.
+func Apply(query string, hits []models.ProductHit, rules []models.MerchRule, fetch PinnedFetcher) ([]models.ProductHit, []models.FiredRule) {
+	queryWords := words(query)
+	limit := len(hits)
+
+	var active []models.MerchRule
+	for _, r := range rules {
+		if r.Enabled && matches(r.Match, queryWords) {
+			active = append(active, r)
+		}
+	}
+
+	var fired []models.FiredRule
+
+	for _, r := range active {
+		if r.Action != models.MerchActionBlock {
+			continue
+		}
+		kept := hits[:0]
+		var ids []string
+		for _, h := range hits {
+			if targets(r.Target, h) {
+				ids = append(ids, productID(h))
+				continue
+			}
+			kept = append(kept, h)
+		}
+		hits = kept
+		if len(ids) > 0 {
+			fired = append(fired, firedRule(r, ids))
+		}
+	}
+
+	rescored := false
+	for _, r := range active {
+		if r.Action != models.MerchActionBoost && r.Action != models.MerchActionBury {
+			continue
+		}
+		var ids []string
+		for i, h := range hits {
+			if !targets(r.Target, h) {
+				continue
+			}
+			if r.Action == models.MerchActionBoost {
+				hits[i].Score = h.Score * (1 + r.Weight)
+			} else {
+				hits[i].Score = h.Score / (1 + r.Weight)
+			}
+			ids = append(ids, productID(h))
+		}
+		if len(ids) > 0 {
+			rescored = true
+			fired = append(fired, firedRule(r, ids))
+		}
+	}
+	if rescored {
+		sort.SliceStable(hits, func(i, j int) bool {
+			return hits[i].Score > hits[j].Score
+		})
+	}
+
+	var pins []models.MerchRule
+	for _, r := range active {
+		if r.Action == models.MerchActionPin {
+			pins = append(pins, r)
+		}
+	}
+	sort.SliceStable(pins, func(i, j int) bool {
+		return pinPosition(pins[i]) < pinPosition(pins[j])
+	})
+
+	for _, r := range pins {
+		var ids []string
+		for offset, id := range r.Target.ProductIDs {
+			var pinned *models.ProductHit
+			hits, pinned = remove(hits, id)
+			if pinned == nil && fetch != nil {
+				p, err := fetch(id)
+				if err != nil {
+					continue
+				}
+				pinned = p
+			}
+			if pinned == nil || blockedBy(active, *pinned) {
+				continue
+			}
+			hits = insert(hits, *pinned, pinPosition(r)-1+offset)
+			ids = append(ids, id)
+		}
+		if len(ids) > 0 {
+			fired = append(fired, firedRule(r, ids))
+		}
+	}
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits, fired
+}
+
+// matches reports whether any of the rule's terms appears in the query as
+// whole words, so "cat" matches "cat toys" but not "category". Terms of
+// several words match as a phrase, with or without the breaks between its
+// words, so a "new balance" brand matches "New Balance sneakers" and
+// "newbalance sneakers".
+func matches(m models.MerchMatch, queryWords []string) bool {
+	if len(m.Keywords) == 0 && len(m.Categories) == 0 && len(m.Brands) == 0 {
+		return true
+	}
+	return containsAny(queryWords, m.Keywords) ||
+		containsAny(queryWords, m.Categories) ||
+		containsAny(queryWords, m.Brands)
+}
+
+func containsAny(queryWords []string, terms []string) bool {
+	for _, t := range terms {
+		if termWords := words(t); len(termWords) > 0 && containsPhrase(queryWords, termWords) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsPhrase reports whether consecutive words of s spell out phrase
+// once the breaks between words are dropped on both sides.
+func containsPhrase(s []string, phrase []string) bool {
+	want := strings.Join(phrase, "")
+	for i := range s {
+		run := ""
+		for _, w := range s[i:] {
+			run += w
+			if len(run) >= len(want) {
+				break
+			}
+		}
+		if run == want {
+			return true
+		}
+	}
+	return false
+}
+
+// words splits text into lowercase words on anything but letters and
+// digits, reduced to their singular so "dresses" matches a "dress" rule.
+// Apostrophes are dropped rather than split on, so "Levi's" is one word.
+func words(text string) []string {
+	text = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(text))
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, f := range fields {
+		fields[i] = singular(f)
+	}
+	return fields
+}
+
+// singular strips common English plural endings. It only has to treat a
+// rule's terms and the query alike, not be grammatical.
+func singular(w string) string {
+	if len(w) <= 3 {
+		return w
+	}
+	for _, end := range []string{"sses", "shes", "ches", "xes", "zes"} {
+		if strings.HasSuffix(w, end) {
+			return w[:len(w)-2]
+		}
+	}
+	if strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+func targets(t models.MerchTarget, h models.ProductHit) bool {
+	id := productID(h)
+	for _, p := range t.ProductIDs {
+		if p == id {
+			return true
+		}
+	}
+	if t.Property == "" {
+		return false
+	}
+	v, ok := h.Properties[t.Property]
+	return ok && strings.EqualFold(fmt.Sprint(v), t.Value)
+}
+
+func blockedBy(active []models.MerchRule, h models.ProductHit) bool {
+	for _, r := range active {
+		if r.Action == models.MerchActionBlock && targets(r.Target, h) {
+			return true
+		}
+	}
+	return false
+}
+
+func productID(h models.ProductHit) string {
+	id, _ := h.Properties["productId"].(string)
+	return id
+}
+
+func pinPosition(r models.MerchRule) int {
+	if r.Position <= 0 {
+		return 1
+	}
+	return r.Position
+}
+
+func remove(hits []models.ProductHit, id string) ([]models.ProductHit, *models.ProductHit) {
+	for i, h := range hits {
+		if productID(h) == id {
+			return append(hits[:i:i], hits[i+1:]...), &h
+		}
+	}
+	return hits, nil
+}
+
+func insert(hits []models.ProductHit, h models.ProductHit, idx int) []models.ProductHit {
+	if idx > len(hits) {
+		idx = len(hits)
+	}
+	hits = append(hits, models.ProductHit{})
+	copy(hits[idx+1:], hits[idx:])
+	hits[idx] = h
+	return hits
+}
+
+func firedRule(r models.MerchRule, ids []string) models.FiredRule {
+	return models.FiredRule{RuleID: r.ID, Name: r.Name, Action: r.Action, ProductIDs: ids}
+}
@@ -0,0 +1,249 @@
+package merch
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+)
+
+func hit(id string, score float64, brand string) models.ProductHit {
+	return models.ProductHit{
+		Score:      score,
+		Properties: map[string]any{"productId": id, "brand": brand},
+	}
+}
+
+// results are ordered by score like search results.
+func results() []models.ProductHit {
+	return []models.ProductHit{
+		hit("p1", 0.9, "acme"),
+		hit("p2", 0.8, "globex"),
+		hit("p3", 0.7, "acme"),
+		hit("p4", 0.6, "initech"),
+	}
+}
+
+func ids(hits []models.ProductHit) []string {
+	out := make([]string, 0, len(hits))
+	for _, h := range hits {
+		out = append(out, productID(h))
+	}
+	return out
+}
+
+func firedIDs(fired []models.FiredRule) []string {
+	out := make([]string, 0, len(fired))
+	for _, f := range fired {
+		out = append(out, f.RuleID)
+	}
+	return out
+}
+
+func fetchFrom(catalog ...models.ProductHit) PinnedFetcher {
+	return func(id string) (*models.ProductHit, error) {
+		for _, h := range catalog {
+			if productID(h) == id {
+				return &h, nil
+			}
+		}
+		return nil, errors.New("not found")
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	pin := func(id string, position int, products ...string) models.MerchRule {
+		return models.MerchRule{ID: id, Enabled: true, Action: models.MerchActionPin, Position: position, Target: models.MerchTarget{ProductIDs: products}}
+	}
+	boost := func(id string, weight float64, brand string) models.MerchRule {
+		return models.MerchRule{ID: id, Enabled: true, Action: models.MerchActionBoost, Weight: weight, Target: models.MerchTarget{Property: "brand", Value: brand}}
+	}
+	bury := func(id string, weight float64, brand string) models.MerchRule {
+		return models.MerchRule{ID: id, Enabled: true, Action: models.MerchActionBury, Weight: weight, Target: models.MerchTarget{Property: "brand", Value: brand}}
+	}
+	block := func(id string, target models.MerchTarget) models.MerchRule {
+		return models.MerchRule{ID: id, Enabled: true, Action: models.MerchActionBlock, Target: target}
+	}
+
+	tests := []struct {
+		name      string
+		rules     []models.MerchRule
+		fetch     PinnedFetcher
+		wantOrder []string
+		wantFired []string
+	}{
+		{
+			name:      "no rules keeps results",
+			wantOrder: []string{"p1", "p2", "p3", "p4"},
+			wantFired: []string{},
+		},
+		{
+			name:      "pin moves a result into its slot",
+			rules:     []models.MerchRule{pin("r1", 1, "p4")},
+			wantOrder: []string{"p4", "p1", "p2", "p3"},
+			wantFired: []string{"r1"},
+		},
+		{
+			name:      "pin defaults to the first slot and fills consecutive slots",
+			rules:     []models.MerchRule{pin("r1", 0, "p3", "p4")},
+			wantOrder: []string{"p3", "p4", "p1", "p2"},
+			wantFired: []string{"r1"},
+		},
+		{
+			name:      "pin fetches a missing product without growing the results",
+			rules:     []models.MerchRule{pin("r1", 2, "p9")},
+			fetch:     fetchFrom(hit("p9", 0, "acme")),
+			wantOrder: []string{"p1", "p9", "p2", "p3"},
+			wantFired: []string{"r1"},
+		},
+		{
+			name:      "pin of an unknown product is skipped",
+			rules:     []models.MerchRule{pin("r1", 1, "p9")},
+			fetch:     fetchFrom(),
+			wantOrder: []string{"p1", "p2", "p3", "p4"},
+			wantFired: []string{},
+		},
+		{
+			name:      "pin past the end lands last",
+			rules:     []models.MerchRule{pin("r1", 10, "p1")},
+			wantOrder: []string{"p2", "p3", "p4", "p1"},
+			wantFired: []string{"r1"},
+		},
+		{
+			name:      "boost reorders by weighted score",
+			rules:     []models.MerchRule{boost("r1", 1, "initech")},
+			wantOrder: []string{"p4", "p1", "p2", "p3"},
+			wantFired: []string{"r1"},
+		},
+		{
+			name:      "bury reorders by weighted score",
+			rules:     []models.MerchRule{bury("r1", 1, "acme")},
+			wantOrder: []string{"p2", "p4", "p1", "p3"},
+			wantFired: []string{"r1"},
+		},
+		{
+			name:      "boost without matching products does not fire",
+			rules:     []models.MerchRule{boost("r1", 1, "umbrella")},
+			wantOrder: []string{"p1", "p2", "p3", "p4"},
+			wantFired: []string{},
+		},
+		{
+			name:      "block filters by property",
+			rules:     []models.MerchRule{block("r1", models.MerchTarget{Property: "brand", Value: "ACME"})},
+			wantOrder: []string{"p2", "p4"},
+			wantFired: []string{"r1"},
+		},
+		{
+			name:      "block filters by product id",
+			rules:     []models.MerchRule{block("r1", models.MerchTarget{ProductIDs: []string{"p2"}})},
+			wantOrder: []string{"p1", "p3", "p4"},
+			wantFired: []string{"r1"},
+		},
+		{
+			name: "block wins over pin",
+			rules: []models.MerchRule{
+				pin("r1", 1, "p4"),
+				block("r2", models.MerchTarget{ProductIDs: []string{"p4"}}),
+			},
+			wantOrder: []string{"p1", "p2", "p3"},
+			wantFired: []string{"r2"},
+		},
+		{
+			name: "block wins over boost",
+			rules: []models.MerchRule{
+				boost("r1", 5, "initech"),
+				block("r2", models.MerchTarget{Property: "brand", Value: "initech"}),
+			},
+			wantOrder: []string{"p1", "p2", "p3"},
+			wantFired: []string{"r2"},
+		},
+		{
+			name: "pin wins over bury",
+			rules: []models.MerchRule{
+				bury("r1", 10, "acme"),
+				pin("r2", 1, "p1"),
+			},
+			wantOrder: []string{"p1", "p2", "p4", "p3"},
+			wantFired: []string{"r1", "r2"},
+		},
+		{
+			name: "pins are placed in slot order",
+			rules: []models.MerchRule{
+				pin("r1", 2, "p4"),
+				pin("r2", 1, "p3"),
+			},
+			wantOrder: []string{"p3", "p4", "p1", "p2"},
+			wantFired: []string{"r2", "r1"},
+		},
+		{
+			name: "disabled rules are ignored",
+			rules: []models.MerchRule{
+				{ID: "r1", Action: models.MerchActionBlock, Target: models.MerchTarget{ProductIDs: []string{"p1"}}},
+			},
+			wantOrder: []string{"p1", "p2", "p3", "p4"},
+			wantFired: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, fired := Apply("shoes", results(), tt.rules, tt.fetch)
+
+			if got := ids(hits); !slices.Equal(got, tt.wantOrder) {
+				t.Errorf("order = %v, want %v", got, tt.wantOrder)
+			}
+			if got := firedIDs(fired); !slices.Equal(got, tt.wantFired) {
+				t.Errorf("fired = %v, want %v", got, tt.wantFired)
+			}
+		})
+	}
+}
+
+func TestApplyMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		match models.MerchMatch
+		want  bool
+	}{
+		{name: "empty match applies to every query", query: "anything", want: true},
+		{name: "keyword as a word", query: "toys for my cat", match: models.MerchMatch{Keywords: []string{"cat"}}, want: true},
+		{name: "keyword inside a word", query: "browse by category", match: models.MerchMatch{Keywords: []string{"cat"}}},
+		{name: "keyword ignores case and punctuation", query: "Cat, please!", match: models.MerchMatch{Keywords: []string{"CAT"}}, want: true},
+		{name: "plural query matches singular keyword", query: "red shoes", match: models.MerchMatch{Keywords: []string{"shoe"}}, want: true},
+		{name: "singular query matches plural keyword", query: "red shoe", match: models.MerchMatch{Keywords: []string{"shoes"}}, want: true},
+		{name: "es plural matches singular keyword", query: "smart watches", match: models.MerchMatch{Keywords: []string{"watch"}}, want: true},
+		{name: "phrase in order", query: "cheap running shoes", match: models.MerchMatch{Keywords: []string{"running shoes"}}, want: true},
+		{name: "phrase out of order", query: "shoes for running", match: models.MerchMatch{Keywords: []string{"running shoes"}}},
+		{name: "category", query: "summer dresses", match: models.MerchMatch{Categories: []string{"dress"}}, want: true},
+		{name: "brand", query: "acme anvils", match: models.MerchMatch{Brands: []string{"Acme"}}, want: true},
+		{name: "brand inside a word", query: "acmeish anvils", match: models.MerchMatch{Brands: []string{"acme"}}},
+		{name: "multi-word category", query: "best running shoes for trails", match: models.MerchMatch{Categories: []string{"Running Shoes"}}, want: true},
+		{name: "multi-word category out of order", query: "shoes for running", match: models.MerchMatch{Categories: []string{"running shoes"}}},
+		{name: "multi-word brand", query: "new balance sneakers", match: models.MerchMatch{Brands: []string{"New Balance"}}, want: true},
+		{name: "multi-word brand written as one word", query: "newbalance sneakers", match: models.MerchMatch{Brands: []string{"New Balance"}}, want: true},
+		{name: "one-word brand written as two words", query: "the north face jackets", match: models.MerchMatch{Brands: []string{"TheNorthFace"}}, want: true},
+		{name: "multi-word brand with one word missing", query: "balance bikes", match: models.MerchMatch{Brands: []string{"new balance"}}},
+		{name: "hyphenated category", query: "plain tshirts", match: models.MerchMatch{Categories: []string{"T-Shirts"}}, want: true},
+		{name: "brand with an apostrophe", query: "levis jeans", match: models.MerchMatch{Brands: []string{"Levi's"}}, want: true},
+		{name: "blank terms never match", query: "shoes", match: models.MerchMatch{Keywords: []string{"", " "}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := models.MerchRule{
+				ID:      "r1",
+				Enabled: true,
+				Match:   tt.match,
+				Action:  models.MerchActionBlock,
+				Target:  models.MerchTarget{ProductIDs: []string{"p1"}},
+			}
+
+			_, fired := Apply(tt.query, results(), []models.MerchRule{rule}, nil)
+			if got := len(fired) == 1; got != tt.want {
+				t.Errorf("matched = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
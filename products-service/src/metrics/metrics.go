@@ -0,0 +1,196 @@
+// Package metrics holds the service's Prometheus collectors. They are
+// registered with the default registry, which promhttp.Handler serves.
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+)
+
+const namespace = "ecomm"
+
+// llmBuckets cover completions from a fast rerank to a long streamed answer.
+var llmBuckets = []float64{0.25, 0.5, 1, 2, 4, 8, 15, 30, 60}
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	StreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sse_stream_duration_seconds",
+		Help:      "Duration of streamed chat answers.",
+		Buckets:   llmBuckets,
+	}, []string{"model"})
+
+	StreamTimeToFirstToken = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sse_time_to_first_token_seconds",
+		Help:      "Time from request to the first streamed answer chunk.",
+		Buckets:   llmBuckets,
+	}, []string{"model"})
+
+	AMQPMessagesConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "amqp_messages_consumed_total",
+		Help:      "Deliveries settled by consumers, by queue and outcome (ack, requeue, reject).",
+	}, []string{"queue", "outcome"})
+
+	AMQPRedeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "amqp_redeliveries_total",
+		Help:      "Deliveries received with the redelivered flag set.",
+	}, []string{"queue"})
+
+	AMQPQueueLag = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "amqp_queue_lag_seconds",
+		Help:      "Time between publishing a message and a consumer receiving it.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 15, 60, 300, 900},
+	}, []string{"queue"})
+
+	AMQPProcessingDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "amqp_processing_duration_seconds",
+		Help:      "Time from a consumer receiving a delivery to settling it.",
+		Buckets:   llmBuckets,
+	}, []string{"queue"})
+
+	WorkersBusy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "amqp_workers_busy",
+		Help:      "Workers currently processing a delivery.",
+	}, []string{"queue"})
+
+	WeaviateQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "weaviate_query_duration_seconds",
+		Help:      "Weaviate request latency by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "status"})
+
+	WeaviateResults = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "weaviate_query_results",
+		Help:      "Objects returned per Weaviate query.",
+		Buckets:   []float64{0, 1, 5, 10, 20, 50, 100},
+	}, []string{"operation"})
+
+	LLMRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "openai_request_duration_seconds",
+		Help:      "OpenAI request latency by task and model.",
+		Buckets:   llmBuckets,
+	}, []string{"task", "model"})
+
+	LLMErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "openai_errors_total",
+		Help:      "Failed OpenAI requests by task and model.",
+	}, []string{"task", "model"})
+
+	LLMRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "openai_retries_total",
+		Help:      "OpenAI requests retried after a failure, by task and model.",
+	}, []string{"task", "model"})
+
+	RedisCommandDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_command_duration_seconds",
+		Help:      "Redis command latency by command. Pipelines are recorded as \"pipeline\".",
+		Buckets:   []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.5},
+	}, []string{"command", "status"})
+)
+
+// HTTPMiddleware records request durations labelled with the matched route
+// template, so path parameters do not explode the series count.
+func HTTPMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if fe, ok := err.(*fiber.Error); ok {
+			status = fe.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+
+		route := c.Route().Path
+		if status == fiber.StatusNotFound && route == "/" && c.Path() != "/" {
+			route = "unmatched"
+		}
+
+		HTTPRequestDuration.WithLabelValues(route, c.Method(), strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// ObserveWeaviate records the latency of one Weaviate request.
+func ObserveWeaviate(operation string, start time.Time, err error) {
+	WeaviateQueryDuration.WithLabelValues(operation, status(err)).Observe(time.Since(start).Seconds())
+}
+
+// ObserveResults records how many objects a Weaviate query returned.
+func ObserveResults(operation string, results int) {
+	WeaviateResults.WithLabelValues(operation).Observe(float64(results))
+}
+
+// ObserveLLM records one OpenAI request attempt.
+func ObserveLLM(task string, model string, start time.Time, err error) {
+	LLMRequestDuration.WithLabelValues(task, model).Observe(time.Since(start).Seconds())
+	if err != nil {
+		LLMErrors.WithLabelValues(task, model).Inc()
+	}
+}
+
+// RedisHook times every command sent through a go-redis client.
+type RedisHook struct{}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		RedisCommandDuration.WithLabelValues(cmd.Name(), redisStatus(err)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		RedisCommandDuration.WithLabelValues("pipeline", redisStatus(err)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// redisStatus does not count a missing key as an error.
+func redisStatus(err error) string {
+	if err == redis.Nil {
+		return "ok"
+	}
+	return status(err)
+}
+
+func status(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -5,9 +5,14 @@ type AiQueryParams struct {
 	SessionID string `json:"sessionId"`
 	UserID    string `json:"userId"`
 	OrgID     string `json:"orgId"`
+	// ProductID is set when the shopper references a product shown in a
+	// previous turn, e.g. "something like this but cheaper".
+	ProductID string `json:"productId,omitempty"`
+	RequestID string `json:"-"`
 }
 
 type MessageChanStruct struct {
 	Chunk string
 	Err   error
+	Meta  *ResponseMeta
 }
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// API key scopes. Admin keys may also ingest and chat.
+const (
+	ScopeIngest = "ingest"
+	ScopeChat   = "chat"
+	ScopeAdmin  = "admin"
+)
+
+var APIKeyScopes = []string{ScopeIngest, ScopeChat, ScopeAdmin}
+
+// APIKey is an org's API key as shown to the org. The secret is only ever
+// returned once, when the key is issued or rotated.
+type APIKey struct {
+	ID        string     `json:"id"`
+	OrgID     string     `json:"orgId"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func (k APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+type IssueAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+func (r IssueAPIKeyRequest) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(r.Scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	for _, s := range r.Scopes {
+		if !isValidScope(s) {
+			return fmt.Errorf("unknown scope %q, expected one of %v", s, APIKeyScopes)
+		}
+	}
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("expiresAt must be in the future")
+	}
+	return nil
+}
+
+func isValidScope(scope string) bool {
+	for _, s := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IssuedAPIKey carries the plaintext key back to the caller once.
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Audited actions.
+const (
+	AuditProductsUpload      = "products.upload"
+	AuditProductsRejected    = "products.rejected"
+	AuditProductsDeleteAll   = "products.delete_all"
+	AuditProductsStock       = "products.stock_update"
+	AuditProductsUpdate      = "products.update"
+	AuditSearchSettingsSet   = "search_settings.update"
+	AuditSearchSettingsReset = "search_settings.reset"
+	AuditMerchRuleCreate     = "merch_rule.create"
+	AuditMerchRuleUpdate     = "merch_rule.update"
+	AuditMerchRuleDelete     = "merch_rule.delete"
+	AuditRetentionSet        = "retention.update"
+	AuditUserErase           = "user.erase"
+	AuditPlanSet             = "quota_plan.update"
+	AuditAPIKeyIssue         = "api_key.issue"
+	AuditAPIKeyRotate        = "api_key.rotate"
+	AuditAPIKeyRevoke        = "api_key.revoke"
+	AuditRoleAssign          = "role.assign"
+	AuditRoleRemove          = "role.remove"
+)
+
+// QuotaAuditActor is the actor of events recorded when a quota refuses work
+// after the request that asked for it has returned.
+const QuotaAuditActor = "quota"
+
+// PlatformAuditOrg holds events of operations that span every org.
+const PlatformAuditOrg = "_platform"
+
+// AuditEvent is an append-only record of a mutating operation.
+type AuditEvent struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	OrgID     string    `json:"orgId"`
+	Actor     string    `json:"actor"`
+	Role      string    `json:"role"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	RequestID string    `json:"requestId"`
+	Before    any       `json:"before,omitempty"`
+	After     any       `json:"after,omitempty"`
+}
+
+type AuditQuery struct {
+	OrgID string
+	Actor string
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+func (q AuditQuery) Validate() error {
+	if q.Limit <= 0 || q.Limit > 500 {
+		return fmt.Errorf("limit must be between 1 and 500")
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && q.To.Before(q.From) {
+		return fmt.Errorf("from must not be after to")
+	}
+	return nil
+}
+
+type AuditPage struct {
+	Events     []AuditEvent `json:"events"`
+	NextCursor string       `json:"nextCursor,omitempty"`
+}
+
+// AuditCursor resumes a page after the event at Time (unix milliseconds),
+// skipping the Skip events stored at that same millisecond that were already
+// scanned.
+type AuditCursor struct {
+	Time int64
+	Skip int64
+}
+
+func (c AuditCursor) String() string {
+	return fmt.Sprintf("%d:%d", c.Time, c.Skip)
+}
+
+func ParseAuditCursor(s string) (AuditCursor, error) {
+	ms, skip, ok := strings.Cut(s, ":")
+	if !ok {
+		return AuditCursor{}, fmt.Errorf("invalid cursor")
+	}
+	t, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil || t <= 0 {
+		return AuditCursor{}, fmt.Errorf("invalid cursor")
+	}
+	n, err := strconv.ParseInt(skip, 10, 64)
+	if err != nil || n <= 0 {
+		return AuditCursor{}, fmt.Errorf("invalid cursor")
+	}
+	return AuditCursor{Time: t, Skip: n}, nil
+}
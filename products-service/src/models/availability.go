@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	AvailabilityInStock    = "in_stock"
+	AvailabilityLowStock   = "low_stock"
+	AvailabilityOutOfStock = "out_of_stock"
+	AvailabilityPreorder   = "preorder"
+)
+
+// availabilityRank orders availabilities from most to least purchasable.
+var availabilityRank = map[string]int{
+	AvailabilityInStock:    0,
+	AvailabilityLowStock:   1,
+	AvailabilityPreorder:   2,
+	AvailabilityOutOfStock: 3,
+}
+
+func ValidAvailability(a string) bool {
+	_, ok := availabilityRank[a]
+	return ok
+}
+
+// BestAvailability summarises variant availabilities into the product level
+// one: a product is purchasable if any of its variants is. Unknown values are
+// ignored and an empty result means availability is not tracked.
+func BestAvailability(availabilities []string) string {
+	best := ""
+	for _, a := range availabilities {
+		rank, ok := availabilityRank[a]
+		if !ok {
+			continue
+		}
+		if best == "" || rank < availabilityRank[best] {
+			best = a
+		}
+	}
+	return best
+}
+
+// RankByAvailability is the one rule for out of stock products in results:
+// they are dropped when inStockOnly is set and otherwise moved behind the
+// purchasable ones, keeping relative order. Products without availability
+// data count as purchasable.
+func RankByAvailability(hits []ProductHit, inStockOnly bool) []ProductHit {
+	if inStockOnly {
+		available := hits[:0]
+		for _, h := range hits {
+			if !h.OutOfStock() {
+				available = append(available, h)
+			}
+		}
+		return available
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return !hits[i].OutOfStock() && hits[j].OutOfStock()
+	})
+	return hits
+}
+
+func (h ProductHit) OutOfStock() bool {
+	availability, _ := h.Properties["availability"].(string)
+	return availability == AvailabilityOutOfStock
+}
+
+type StockUpdateRequest struct {
+	Skus []SkuStockUpdate `json:"skus"`
+}
+
+type SkuStockUpdate struct {
+	SkuID        string `json:"skuId"`
+	Availability string `json:"availability"`
+	Stock        *int   `json:"stock,omitempty"`
+}
+
+func (r StockUpdateRequest) Validate() error {
+	if len(r.Skus) == 0 {
+		return fmt.Errorf("skus must not be empty")
+	}
+	for _, s := range r.Skus {
+		if s.SkuID == "" {
+			return fmt.Errorf("skuId is required")
+		}
+		if !ValidAvailability(s.Availability) {
+			return fmt.Errorf("invalid availability %q for sku %s", s.Availability, s.SkuID)
+		}
+		if s.Stock != nil && *s.Stock < 0 {
+			return fmt.Errorf("stock must not be negative for sku %s", s.SkuID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// ResponseMeta is sent once on the message channel before the first chunk so
+// the handler can record what the answer was generated from.
+type ResponseMeta struct {
+	Model      string
+	ProductIDs []string
+}
+
+// ChatTurn is one stored query and answer of a shopper session.
+type ChatTurn struct {
+	RequestID  string   `json:"requestId"`
+	Query      string   `json:"query"`
+	ProductIDs []string `json:"productIds"`
+	Response   string   `json:"response"`
+	Model      string   `json:"model"`
+	Error      string   `json:"error,omitempty"`
+	// Interrupted turns ended when the shopper disconnected; Response holds
+	// what was streamed until then.
+	Interrupted  bool      `json:"interrupted,omitempty"`
+	StartedAt    time.Time `json:"startedAt"`
+	FirstTokenMs int64     `json:"firstTokenMs"`
+	TotalMs      int64     `json:"totalMs"`
+}
+
+type SessionSummary struct {
+	SessionID  string    `json:"sessionId"`
+	LastActive time.Time `json:"lastActive"`
+	Turns      int64     `json:"turns"`
+}
@@ -0,0 +1,23 @@
+package models
+
+const (
+	HealthUp   = "up"
+	HealthDown = "down"
+)
+
+// HealthReport is the readiness probe's response. Status is down when any
+// required component is down.
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+type ComponentHealth struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+	// Optional components do not affect the overall status.
+	Optional bool `json:"optional,omitempty"`
+	// Cached is set when the result is from an earlier probe.
+	Cached bool `json:"cached,omitempty"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	MerchActionPin   = "pin"
+	MerchActionBoost = "boost"
+	MerchActionBury  = "bury"
+	MerchActionBlock = "block"
+)
+
+// MerchRule is a merchant-defined adjustment applied to search results
+// before they reach the LLM.
+type MerchRule struct {
+	ID      string      `json:"id"`
+	Name    string      `json:"name"`
+	Enabled bool        `json:"enabled"`
+	Match   MerchMatch  `json:"match"`
+	Action  string      `json:"action"`
+	Target  MerchTarget `json:"target"`
+	// Weight scales boost and bury: scores are multiplied or divided by
+	// 1 + Weight.
+	Weight float64 `json:"weight,omitempty"`
+	// Position is the 1-based slot a pinned product is placed in.
+	Position  int       `json:"position,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// MerchMatch decides whether a rule applies to a query. An empty match
+// applies to every query.
+type MerchMatch struct {
+	Keywords   []string `json:"keywords,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+	Brands     []string `json:"brands,omitempty"`
+}
+
+// MerchTarget selects the products a rule acts on, either by product ID or
+// by a stored property value such as brand.
+type MerchTarget struct {
+	ProductIDs []string `json:"productIds,omitempty"`
+	Property   string   `json:"property,omitempty"`
+	Value      string   `json:"value,omitempty"`
+}
+
+type FiredRule struct {
+	RuleID     string   `json:"ruleId"`
+	Name       string   `json:"name"`
+	Action     string   `json:"action"`
+	ProductIDs []string `json:"productIds"`
+}
+
+type MerchAudit struct {
+	RequestID string      `json:"requestId"`
+	UserID    string      `json:"userId"`
+	Query     string      `json:"query"`
+	Fired     []FiredRule `json:"fired"`
+	Time      time.Time   `json:"time"`
+}
+
+func (r MerchRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch r.Action {
+	case MerchActionPin, MerchActionBoost, MerchActionBury, MerchActionBlock:
+	default:
+		return fmt.Errorf("action must be one of pin, boost, bury or block")
+	}
+	if len(r.Target.ProductIDs) == 0 && (r.Target.Property == "" || r.Target.Value == "") {
+		return fmt.Errorf("target needs productIds or a property and value")
+	}
+	if r.Action == MerchActionPin && len(r.Target.ProductIDs) == 0 {
+		return fmt.Errorf("pin rules must target productIds")
+	}
+	if (r.Action == MerchActionBoost || r.Action == MerchActionBury) && r.Weight <= 0 {
+		return fmt.Errorf("weight must be positive for boost and bury")
+	}
+	if r.Position < 0 {
+		return fmt.Errorf("position must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// ShopperPreferences is the typed, per-user memory maintained across turns.
+type ShopperPreferences struct {
+	// Sizes maps a product kind to the shopper's size, e.g. "shoe": "9".
+	Sizes          map[string]string `json:"sizes"`
+	Budget         *Budget           `json:"budget,omitempty"`
+	BrandsLiked    []string          `json:"brandsLiked"`
+	BrandsDisliked []string          `json:"brandsDisliked"`
+	Colors         []string          `json:"colors"`
+	// Exclusions are hard constraints such as "vegan" or "no leather".
+	Exclusions []string  `json:"exclusions"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type Budget struct {
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+	Currency string   `json:"currency,omitempty"`
+}
+
+func (p ShopperPreferences) Validate() error {
+	if p.Budget == nil {
+		return nil
+	}
+	if p.Budget.Min != nil && *p.Budget.Min < 0 {
+		return fmt.Errorf("budget min must not be negative")
+	}
+	if p.Budget.Max != nil && *p.Budget.Max < 0 {
+		return fmt.Errorf("budget max must not be negative")
+	}
+	if p.Budget.Min != nil && p.Budget.Max != nil && *p.Budget.Min > *p.Budget.Max {
+		return fmt.Errorf("budget min must not exceed max")
+	}
+	return nil
+}
+
+func (p ShopperPreferences) IsEmpty() bool {
+	return len(p.Sizes) == 0 && p.Budget == nil && len(p.BrandsLiked) == 0 &&
+		len(p.BrandsDisliked) == 0 && len(p.Colors) == 0 && len(p.Exclusions) == 0
+}
+
+// ApplyToSearch narrows a search with the hard preferences: budget and
+// disliked brands. Bounds given in the search win, and a budget bound that
+// would leave no valid price range next to them is skipped.
+func (p ShopperPreferences) ApplyToSearch(params SearchParams) SearchParams {
+	if b := p.Budget; b != nil {
+		explicitMin, explicitMax := params.MinPrice, params.MaxPrice
+		if b.Min != nil && explicitMin == nil && (explicitMax == nil || *b.Min <= *explicitMax) {
+			params.MinPrice = b.Min
+		}
+		if b.Max != nil && explicitMax == nil && (explicitMin == nil || *b.Max >= *explicitMin) {
+			params.MaxPrice = b.Max
+		}
+	}
+	params.ExcludeBrands = append(params.ExcludeBrands, p.BrandsDisliked...)
+	return params
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func price(v float64) *float64 {
+	return &v
+}
+
+func TestApplyToSearchBudget(t *testing.T) {
+	tests := []struct {
+		name             string
+		budget           *Budget
+		minPrice         *float64
+		maxPrice         *float64
+		wantMin, wantMax *float64
+	}{
+		{name: "no budget", minPrice: price(10), wantMin: price(10)},
+		{name: "budget fills both bounds", budget: &Budget{Min: price(20), Max: price(80)}, wantMin: price(20), wantMax: price(80)},
+		{name: "explicit bounds win", budget: &Budget{Min: price(20), Max: price(80)}, minPrice: price(30), maxPrice: price(60), wantMin: price(30), wantMax: price(60)},
+		{name: "budget min above explicit max is skipped", budget: &Budget{Min: price(100)}, maxPrice: price(50), wantMax: price(50)},
+		{name: "budget max below explicit min is skipped", budget: &Budget{Max: price(40)}, minPrice: price(50), wantMin: price(50)},
+		{name: "compatible budget min is kept", budget: &Budget{Min: price(30)}, maxPrice: price(50), wantMin: price(30), wantMax: price(50)},
+		{name: "budget min equal to explicit max is kept", budget: &Budget{Min: price(50)}, maxPrice: price(50), wantMin: price(50), wantMax: price(50)},
+		{
+			name:     "only the conflicting bound is skipped",
+			budget:   &Budget{Min: price(100), Max: price(200)},
+			maxPrice: price(50),
+			wantMax:  price(50),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefs := ShopperPreferences{Budget: tt.budget}
+			got := prefs.ApplyToSearch(SearchParams{MinPrice: tt.minPrice, MaxPrice: tt.maxPrice})
+
+			if !samePrice(got.MinPrice, tt.wantMin) {
+				t.Errorf("MinPrice = %v, want %v", deref(got.MinPrice), deref(tt.wantMin))
+			}
+			if !samePrice(got.MaxPrice, tt.wantMax) {
+				t.Errorf("MaxPrice = %v, want %v", deref(got.MaxPrice), deref(tt.wantMax))
+			}
+		})
+	}
+}
+
+func TestApplyToSearchExcludesDislikedBrands(t *testing.T) {
+	prefs := ShopperPreferences{BrandsDisliked: []string{"globex"}}
+	got := prefs.ApplyToSearch(SearchParams{ExcludeBrands: []string{"initech"}})
+
+	if want := []string{"initech", "globex"}; !slices.Equal(got.ExcludeBrands, want) {
+		t.Errorf("ExcludeBrands = %v, want %v", got.ExcludeBrands, want)
+	}
+}
+
+func samePrice(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func deref(p *float64) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ProductUpdate is the fast-path message for partial variant changes. Only
+// non-nil fields are written, and the product is not re-enriched.
+type ProductUpdate struct {
+	OrgID     string      `json:"orgId"`
+	ProductID string      `json:"productId"`
+	Skus      []SkuUpdate `json:"skus"`
+}
+
+type SkuUpdate struct {
+	SkuID        string  `json:"skuId"`
+	Price        *string `json:"price,omitempty"`
+	SalePrice    *string `json:"salePrice,omitempty"`
+	Availability *string `json:"availability,omitempty"`
+	Stock        *int    `json:"stock,omitempty"`
+	OnClickURL   *string `json:"onClickUrl,omitempty"`
+}
+
+type ProductUpdatesRequest struct {
+	Updates []ProductUpdate `json:"updates"`
+}
+
+func (u ProductUpdate) Validate() error {
+	if u.OrgID == "" || u.ProductID == "" {
+		return fmt.Errorf("orgId and productId are required")
+	}
+	if len(u.Skus) == 0 {
+		return fmt.Errorf("skus must not be empty for product %s", u.ProductID)
+	}
+	for _, s := range u.Skus {
+		if s.SkuID == "" {
+			return fmt.Errorf("skuId is required for product %s", u.ProductID)
+		}
+		if s.Price != nil {
+			if _, err := strconv.ParseFloat(*s.Price, 64); err != nil {
+				return fmt.Errorf("invalid price %q for sku %s", *s.Price, s.SkuID)
+			}
+		}
+		if s.SalePrice != nil && *s.SalePrice != "" {
+			if _, err := strconv.ParseFloat(*s.SalePrice, 64); err != nil {
+				return fmt.Errorf("invalid salePrice %q for sku %s", *s.SalePrice, s.SkuID)
+			}
+		}
+		if s.Availability != nil && !ValidAvailability(*s.Availability) {
+			return fmt.Errorf("invalid availability %q for sku %s", *s.Availability, s.SkuID)
+		}
+		if s.Stock != nil && *s.Stock < 0 {
+			return fmt.Errorf("stock must not be negative for sku %s", s.SkuID)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package models
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+)
 
 type ProductsModel struct {
 	Products []Product `json:"products"`
@@ -24,7 +27,10 @@ type ProdAttr struct {
 	AssociateValue     string `json:"associateValue"`
 	Image              string `json:"image"`
 	Price              string `json:"price"`
+	SalePrice          string `json:"salePrice,omitempty"`
 	OnClickURL         string `json:"onClickUrl"`
+	Availability       string `json:"availability"`
+	Stock              *int   `json:"stock,omitempty"`
 }
 
 func (p Product) ToFlatMap() map[string]interface{} {
@@ -37,6 +43,12 @@ func (p Product) ToFlatMap() map[string]interface{} {
 		"priceCurrency": p.PriceCurrency,
 	}
 
+	if price, ok := p.MinPrice(); ok {
+		m["minPrice"] = price
+	}
+
+	availabilities := make([]string, 0, len(p.Attributes))
+
 	// Flatten all attributes
 	for i, a := range p.Attributes {
 		prefix := fmt.Sprintf("attr_%d_", i+1)
@@ -48,8 +60,58 @@ func (p Product) ToFlatMap() map[string]interface{} {
 		m[prefix+"associateValue"] = a.AssociateValue
 		m[prefix+"image"] = a.Image
 		m[prefix+"price"] = a.Price
+		if a.SalePrice != "" {
+			m[prefix+"salePrice"] = a.SalePrice
+		}
 		m[prefix+"onClickUrl"] = a.OnClickURL
+
+		if a.Availability != "" {
+			m[prefix+"availability"] = a.Availability
+			availabilities = append(availabilities, a.Availability)
+		}
+		if a.Stock != nil {
+			m[prefix+"stock"] = *a.Stock
+		}
+	}
+
+	if availability := BestAvailability(availabilities); availability != "" {
+		m["availability"] = availability
 	}
 
 	return m
 }
+
+// MinPrice returns the lowest parseable effective attribute price of the
+// product.
+func (p Product) MinPrice() (float64, bool) {
+	prices := make([]string, 0, len(p.Attributes))
+	for _, a := range p.Attributes {
+		prices = append(prices, EffectivePrice(a.Price, a.SalePrice))
+	}
+	return LowestPrice(prices)
+}
+
+// EffectivePrice is the sale price when one is set, otherwise the list price.
+func EffectivePrice(price string, salePrice string) string {
+	if salePrice != "" {
+		return salePrice
+	}
+	return price
+}
+
+// LowestPrice returns the lowest parseable price.
+func LowestPrice(prices []string) (float64, bool) {
+	var minPrice float64
+	found := false
+	for _, raw := range prices {
+		price, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			continue
+		}
+		if !found || price < minPrice {
+			minPrice = price
+			found = true
+		}
+	}
+	return minPrice, found
+}
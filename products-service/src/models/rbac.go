@@ -0,0 +1,120 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Roles a principal can hold in an org. RoleSuperAdmin is held only by the
+// platform key and spans every org.
+const (
+	RoleOwner          = "owner"
+	RoleAdmin          = "admin"
+	RoleCatalogManager = "catalog_manager"
+	RoleAnalyst        = "analyst"
+	RoleWidget         = "widget"
+	RoleSuperAdmin     = "super_admin"
+)
+
+var OrgRoles = []string{RoleOwner, RoleAdmin, RoleCatalogManager, RoleAnalyst, RoleWidget}
+
+// Permissions checked by the routes.
+const (
+	PermChat           = "chat"
+	PermShopperData    = "shopper_data"
+	PermShopperTokens  = "shopper_tokens"
+	PermCatalogWrite   = "catalog_write"
+	PermMerchWrite     = "merch_write"
+	PermSettingsRead   = "settings_read"
+	PermSettingsWrite  = "settings_write"
+	PermAnalytics      = "analytics"
+	PermUsersErase     = "users_erase"
+	PermKeysManage     = "keys_manage"
+	PermRolesManage    = "roles_manage"
+	PermAuditRead      = "audit_read"
+	PermPlatformManage = "platform_manage"
+)
+
+var rolePermissions = map[string][]string{
+	RoleOwner: {
+		PermChat, PermShopperData, PermShopperTokens, PermCatalogWrite, PermMerchWrite,
+		PermSettingsRead, PermSettingsWrite, PermAnalytics, PermUsersErase, PermKeysManage, PermRolesManage,
+		PermAuditRead,
+	},
+	RoleAdmin: {
+		PermChat, PermShopperData, PermShopperTokens, PermCatalogWrite, PermMerchWrite,
+		PermSettingsRead, PermSettingsWrite, PermAnalytics, PermUsersErase, PermKeysManage, PermRolesManage,
+		PermAuditRead,
+	},
+	RoleCatalogManager: {PermChat, PermCatalogWrite, PermMerchWrite, PermSettingsRead},
+	RoleAnalyst:        {PermChat, PermSettingsRead, PermAnalytics},
+	RoleWidget:         {PermChat, PermShopperData, PermShopperTokens},
+}
+
+func RoleHasPermission(role string, perm string) bool {
+	if role == RoleSuperAdmin {
+		return true
+	}
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionScope is the API key scope a permission needs on top of the
+// key's role.
+func PermissionScope(perm string) string {
+	switch perm {
+	case PermCatalogWrite:
+		return ScopeIngest
+	case PermChat, PermShopperData, PermShopperTokens:
+		return ScopeChat
+	}
+	return ScopeAdmin
+}
+
+// DefaultKeyRole is the role of an API key without an assignment, derived
+// from its widest scope. Keys with the widget role can only issue shopper
+// tokens.
+func DefaultKeyRole(key APIKey) string {
+	switch {
+	case key.HasScope(ScopeAdmin):
+		return RoleAdmin
+	case key.HasScope(ScopeIngest):
+		return RoleCatalogManager
+	default:
+		return RoleWidget
+	}
+}
+
+// Principals are "key:<api key id>" or "user:<token subject>".
+func KeyPrincipal(keyID string) string {
+	return "key:" + keyID
+}
+
+func UserPrincipal(userID string) string {
+	return "user:" + userID
+}
+
+type RoleAssignment struct {
+	Principal  string    `json:"principal"`
+	Role       string    `json:"role"`
+	AssignedBy string    `json:"assignedBy"`
+	AssignedAt time.Time `json:"assignedAt"`
+}
+
+func ValidateRoleAssignment(principal string, role string) error {
+	kind, id, ok := strings.Cut(principal, ":")
+	if !ok || id == "" || (kind != "key" && kind != "user") {
+		return fmt.Errorf("principal must be key:<id> or user:<id>")
+	}
+	for _, r := range OrgRoles {
+		if r == role {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown role %q, expected one of %v", role, OrgRoles)
+}
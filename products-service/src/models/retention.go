@@ -0,0 +1,63 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// RetentionPolicy controls how long shopper data is kept for an org.
+type RetentionPolicy struct {
+	QueriesTTLHours int `json:"queriesTtlHours"`
+	ChatTTLHours    int `json:"chatTtlHours"`
+	HistoryTTLHours int `json:"historyTtlHours"`
+}
+
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		QueriesTTLHours: 48,
+		ChatTTLHours:    48,
+		HistoryTTLHours: 24 * 30,
+	}
+}
+
+func (r RetentionPolicy) Validate() error {
+	const maxHours = 24 * 365
+	for name, h := range map[string]int{
+		"queriesTtlHours": r.QueriesTTLHours,
+		"chatTtlHours":    r.ChatTTLHours,
+		"historyTtlHours": r.HistoryTTLHours,
+	} {
+		if h <= 0 || h > maxHours {
+			return fmt.Errorf("%s must be between 1 and %d", name, maxHours)
+		}
+	}
+	return nil
+}
+
+func (r RetentionPolicy) QueriesTTL() time.Duration {
+	return time.Duration(r.QueriesTTLHours) * time.Hour
+}
+
+func (r RetentionPolicy) ChatTTL() time.Duration {
+	return time.Duration(r.ChatTTLHours) * time.Hour
+}
+
+func (r RetentionPolicy) HistoryTTL() time.Duration {
+	return time.Duration(r.HistoryTTLHours) * time.Hour
+}
+
+// LongestTTL is how long the longest-lived shopper data is kept.
+func (r RetentionPolicy) LongestTTL() time.Duration {
+	return max(r.QueriesTTL(), r.ChatTTL(), r.HistoryTTL())
+}
+
+// ErasureReceipt is returned after a shopper's data has been deleted.
+type ErasureReceipt struct {
+	ReceiptID           string         `json:"receiptId"`
+	OrgID               string         `json:"orgId"`
+	UserID              string         `json:"userId"`
+	SessionID           string         `json:"sessionId,omitempty"`
+	DeletedKeys         map[string]int `json:"deletedKeys"`
+	AuditEntriesRemoved int            `json:"auditEntriesRemoved"`
+	CompletedAt         time.Time      `json:"completedAt"`
+}
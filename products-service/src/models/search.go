@@ -0,0 +1,43 @@
+package models
+
+type SearchParams struct {
+	OrgID string
+	Query string
+	Brand string
+	// ExcludeBrands drops products from any of these brands.
+	ExcludeBrands []string
+	MinPrice      *float64
+	MaxPrice      *float64
+	// InStockOnly drops products whose variants are all out of stock or
+	// whose availability is not tracked.
+	InStockOnly bool
+	Limit       int // 0 uses the org search settings limit
+	Offset      int
+}
+
+type SearchResult struct {
+	Products   []ProductHit             `json:"products"`
+	NextCursor string                   `json:"nextCursor,omitempty"`
+	Facets     map[string][]FacetBucket `json:"facets"`
+	// FacetsApproximate is set when a query matched more products than facets
+	// were counted over; counts then cover the top results only.
+	FacetsApproximate bool `json:"facetsApproximate,omitempty"`
+}
+
+type ProductHit struct {
+	ID         string         `json:"id"`
+	Score      float64        `json:"score"`
+	Properties map[string]any `json:"properties"`
+	// ScoreDebug breaks down how a reranker arrived at Score.
+	ScoreDebug map[string]float64 `json:"scoreDebug,omitempty"`
+}
+
+type FacetBucket struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type SimilarParams struct {
+	SearchParams
+	ProductID string
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/Adityadangi14/ecomm_ai/config"
+)
+
+const (
+	FusionRanked        = "ranked"
+	FusionRelativeScore = "relativeScore"
+)
+
+const (
+	RerankerNone    = "none"
+	RerankerLLM     = "llm"
+	RerankerFeature = "feature"
+)
+
+// SearchSettings tunes the Weaviate hybrid query for a single org.
+type SearchSettings struct {
+	Alpha      float32  `json:"alpha"`
+	Limit      int      `json:"limit"`
+	FusionType string   `json:"fusionType"`
+	Properties []string `json:"properties"`
+	Autocut    int      `json:"autocut"`
+
+	// Reranker selects the stage run between retrieval and generation.
+	// RerankCandidates results are retrieved and reranked down to Limit.
+	Reranker         string `json:"reranker"`
+	RerankCandidates int    `json:"rerankCandidates"`
+	RerankDebug      bool   `json:"rerankDebug"`
+}
+
+// DefaultSearchSettings returns the settings of orgs that have not saved
+// their own, which are tuned in the runtime config.
+func DefaultSearchSettings(d config.SearchDefaults) SearchSettings {
+	return SearchSettings{
+		Alpha:      d.Alpha,
+		Limit:      d.Limit,
+		FusionType: d.FusionType,
+
+		Reranker:         d.Reranker,
+		RerankCandidates: d.RerankCandidates,
+	}
+}
+
+var weightedPropertyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\^[0-9]+(\.[0-9]+)?)?$`)
+
+func (s SearchSettings) Validate() error {
+	if s.Alpha < 0 || s.Alpha > 1 {
+		return fmt.Errorf("alpha must be between 0 and 1")
+	}
+	if s.Limit <= 0 || s.Limit > 100 {
+		return fmt.Errorf("limit must be between 1 and 100")
+	}
+	if s.FusionType != FusionRanked && s.FusionType != FusionRelativeScore {
+		return fmt.Errorf("fusionType must be %q or %q", FusionRanked, FusionRelativeScore)
+	}
+	if s.Autocut < 0 {
+		return fmt.Errorf("autocut must not be negative")
+	}
+	if s.Autocut > 0 && s.FusionType != FusionRelativeScore {
+		return fmt.Errorf("autocut requires fusionType %q", FusionRelativeScore)
+	}
+	switch s.Reranker {
+	case RerankerNone, RerankerLLM, RerankerFeature:
+	default:
+		return fmt.Errorf("reranker must be one of %q, %q or %q", RerankerNone, RerankerLLM, RerankerFeature)
+	}
+	if s.Reranker != RerankerNone && (s.RerankCandidates < s.Limit || s.RerankCandidates > 100) {
+		return fmt.Errorf("rerankCandidates must be between limit and 100")
+	}
+	for _, p := range s.Properties {
+		if !weightedPropertyRe.MatchString(p) {
+			return fmt.Errorf("invalid property weight %q, expected name or name^weight", p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// ShopperToken is a short-lived token the chat widget uses on behalf of an
+// anonymous shopper.
+type ShopperToken struct {
+	Token     string    `json:"token"`
+	UserID    string    `json:"userId"`
+	SessionID string    `json:"sessionId"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
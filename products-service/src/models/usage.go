@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// LLM call sites, used to attribute token usage.
+const (
+	UsageTaskImageDescription = "image_description"
+	UsageTaskSemanticText     = "semantic_text"
+	UsageTaskQuerySummary     = "query_summary"
+	UsageTaskChatSummary      = "chat_summary"
+	UsageTaskRerank           = "rerank"
+	UsageTaskPreferences      = "preferences"
+	UsageTaskResponse         = "response"
+)
+
+// UsageRecord is the token usage of a single OpenAI call.
+type UsageRecord struct {
+	OrgID            string
+	UserID           string
+	Task             string
+	Model            string
+	PromptTokens     int64
+	CompletionTokens int64
+	Time             time.Time
+}
+
+type UsageLine struct {
+	Task             string  `json:"task,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	UserID           string  `json:"userId,omitempty"`
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens"`
+	EstimatedCost    float64 `json:"estimatedCost"`
+}
+
+type DailyUsage struct {
+	Date  string      `json:"date"`
+	Lines []UsageLine `json:"lines"`
+	Total UsageLine   `json:"total"`
+}
+
+type UsageReport struct {
+	OrgID    string       `json:"orgId"`
+	From     string       `json:"from"`
+	To       string       `json:"to"`
+	Currency string       `json:"currency"`
+	Days     []DailyUsage `json:"days"`
+	ByUser   []UsageLine  `json:"byUser"`
+	Total    UsageLine    `json:"total"`
+}
+
+// QuotaStatus is an org's plan and how much of it has been used.
+type QuotaStatus struct {
+	Plan                 string `json:"plan"`
+	ChatPerMinute        int    `json:"chatPerMinute"`
+	ChatPerMinutePerUser int    `json:"chatPerMinutePerUser"`
+	UploadsPerDay        int    `json:"uploadsPerDay"`
+	UploadsToday         int    `json:"uploadsToday"`
+	MonthlyTokens        int64  `json:"monthlyTokens"`
+	TokensThisMonth      int64  `json:"tokensThisMonth"`
+}
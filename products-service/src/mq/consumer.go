@@ -3,12 +3,20 @@ package mq
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"log/slog"
+	"sync"
+	"time"
 
+	"github.com/Adityadangi14/ecomm_ai/pkg/logger"
+	"github.com/Adityadangi14/ecomm_ai/pkg/tracing"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/llm"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/quota"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/repository"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
 	"github.com/streadway/amqp"
 )
 
@@ -41,10 +49,63 @@ type ProductConsumer struct {
 	amqpConn *amqp.Connection
 	prodRepo repository.ProductRepository
 	Aiclient llm.Aiclient
+	limiter  quota.Limiter
+	rdb      *redis.Client
+	log      *slog.Logger
+
+	mu    sync.Mutex
+	pools map[string]*workerPool
+
+	// work is the context handlers run under. It outlives the consumers so
+	// in-flight jobs can finish after consuming stops, until Abort.
+	work  context.Context
+	abort context.CancelFunc
+}
+
+func NewProductsConsumer(ampqConn *amqp.Connection, prodRep repository.ProductRepository, aiClient llm.Aiclient, limiter quota.Limiter, rdb *redis.Client, log *slog.Logger) *ProductConsumer {
+	work, abort := context.WithCancel(context.Background())
+	return &ProductConsumer{amqpConn: ampqConn, prodRepo: prodRep, Aiclient: aiClient, limiter: limiter, rdb: rdb, log: log, pools: map[string]*workerPool{}, work: work, abort: abort}
+}
+
+// Abort cancels the context of in-flight jobs, which then reject their
+// deliveries for redelivery.
+func (p *ProductConsumer) Abort() {
+	p.abort()
+}
+
+// Running reports whether a consumer is receiving from queueName.
+func (p *ProductConsumer) Running(queueName string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pools[queueName] != nil
 }
 
-func NewProductsConsumer(ampqConn *amqp.Connection, prodRep repository.ProductRepository, aiClient llm.Aiclient) *ProductConsumer {
-	return &ProductConsumer{amqpConn: ampqConn, prodRepo: prodRep, Aiclient: aiClient}
+// Resize changes the number of workers consuming queueName. Workers beyond
+// the new size stop after their current delivery.
+func (p *ProductConsumer) Resize(queueName string, size int) error {
+	p.mu.Lock()
+	pool := p.pools[queueName]
+	p.mu.Unlock()
+	if pool == nil {
+		return errors.Errorf("no consumer running for %s", queueName)
+	}
+
+	old := pool.size()
+	if err := pool.resize(size); err != nil {
+		return err
+	}
+	p.log.Info("resized worker pool", "queue", queueName, "old", old, "new", size)
+	return nil
+}
+
+func (p *ProductConsumer) setPool(queueName string, pool *workerPool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pool == nil {
+		delete(p.pools, queueName)
+		return
+	}
+	p.pools[queueName] = pool
 }
 
 func (p *ProductConsumer) CreateChannel(exchangeName, queueName, bindingKey, consumerTag string) (*amqp.Channel, error) {
@@ -101,51 +162,161 @@ func (p *ProductConsumer) CreateChannel(exchangeName, queueName, bindingKey, con
 	return ch, nil
 }
 
-func (p *ProductConsumer) worker(ctx context.Context, id int, jobs <-chan amqp.Delivery) {
-	for delivery := range jobs {
-		// fmt.Printf("Worker %d processing: %s\n", id, delivery.Body)
+func (p *ProductConsumer) worker(ctx context.Context, id int, delivery amqp.Delivery) {
+	jobCtx := logger.With(ctx, logger.JobIDKey, delivery.MessageId, "worker", id)
+
+	var body models.Product
+	if err := json.Unmarshal(delivery.Body, &body); err != nil {
+		p.log.ErrorContext(jobCtx, "invalid product JSON", "err", err)
+		_ = delivery.Reject(false)
+		return
+	}
+	jobCtx = logger.With(jobCtx, logger.OrgIDKey, body.OrgID, "productId", body.ID)
 
-		var body models.Product
-		if err := json.Unmarshal(delivery.Body, &body); err != nil {
-			fmt.Printf("Worker %d: invalid JSON: %v\n", id, err)
+	// Redeliveries were already counted on their first attempt.
+	if !delivery.Redelivered {
+		err := p.limiter.AllowIngest(jobCtx, body.OrgID)
+		var exceeded *quota.ExceededError
+		if errors.As(err, &exceeded) {
+			p.log.WarnContext(jobCtx, "dropping product over quota", "err", err)
+			p.recordRejection(jobCtx, delivery, body, exceeded)
 			_ = delivery.Reject(false)
-			continue
+			return
 		}
+		if err != nil {
+			p.log.WarnContext(jobCtx, "quota check failed, processing anyway", "err", err)
+		}
+	}
 
-		res, err := p.Aiclient.ProcessProduct(body)
+	res, err := p.Aiclient.ProcessProduct(jobCtx, body)
 
-		if err != nil {
+	if err != nil {
 
-			fmt.Println("error in processing product", err)
+		p.log.ErrorContext(jobCtx, "error in processing product", "err", err)
 
+		_ = delivery.Reject(true)
+	} else {
+		err = p.prodRepo.SaveProduct(jobCtx, res)
+		if err != nil {
+			p.log.ErrorContext(jobCtx, "save failed", "err", err)
 			_ = delivery.Reject(true)
-		} else {
-			fmt.Println("product to save ", res)
-			err = p.prodRepo.SaveProduct(ctx, res)
-			if err != nil {
-				fmt.Printf("Worker %d: save failed: %v\n", id, err)
-				_ = delivery.Reject(true)
-				continue
-			}
-
-			if err := delivery.Ack(false); err != nil {
-				fmt.Printf("Worker %d: Ack failed: %v\n", id, err)
-			}
+			return
+		}
+		p.log.InfoContext(jobCtx, "product saved")
+
+		if err := delivery.Ack(false); err != nil {
+			p.log.ErrorContext(jobCtx, "ack failed", "err", err)
 		}
+	}
+
+}
+
+// recordRejection appends a rejection event to the org's audit log so the
+// merchant can see which products were dropped over quota.
+func (p *ProductConsumer) recordRejection(ctx context.Context, delivery amqp.Delivery, product models.Product, exceeded *quota.ExceededError) {
+	event := models.AuditEvent{
+		ID:        uuid.New().String(),
+		Time:      time.Now(),
+		OrgID:     product.OrgID,
+		Actor:     models.QuotaAuditActor,
+		Action:    models.AuditProductsRejected,
+		Target:    product.ID,
+		RequestID: delivery.MessageId,
+		After:     map[string]any{"reason": exceeded.Error(), "limit": exceeded.Limit},
+	}
+	if err := helpers.AppendAuditEvent(ctx, p.rdb, event); err != nil {
+		p.log.ErrorContext(ctx, "failed to record rejected product", "err", err)
+	}
+}
+
+// updateWorker applies fast-path partial updates without re-enrichment.
+func (p *ProductConsumer) updateWorker(ctx context.Context, id int, delivery amqp.Delivery) {
+	jobCtx := logger.With(ctx, logger.JobIDKey, delivery.MessageId, "worker", id)
 
+	var update models.ProductUpdate
+	if err := json.Unmarshal(delivery.Body, &update); err != nil {
+		p.log.ErrorContext(jobCtx, "invalid update JSON", "err", err)
+		_ = delivery.Reject(false)
+		return
+	}
+	jobCtx = logger.With(jobCtx, logger.OrgIDKey, update.OrgID, "productId", update.ProductID)
+
+	if err := update.Validate(); err != nil {
+		p.log.ErrorContext(jobCtx, "invalid update", "err", err)
+		_ = delivery.Reject(false)
+		return
+	}
+
+	err := p.prodRepo.PatchVariants(jobCtx, update)
+	if errors.Is(err, repository.ErrProductNotFound) {
+		p.log.WarnContext(jobCtx, "product to update not found")
+		_ = delivery.Reject(false)
+		return
+	}
+	if err != nil {
+		p.log.ErrorContext(jobCtx, "patch failed", "err", err)
+		_ = delivery.Reject(true)
+		return
+	}
+
+	if err := delivery.Ack(false); err != nil {
+		p.log.ErrorContext(jobCtx, "ack failed", "err", err)
+	}
+}
+
+// auditWorker appends audit events to the per-org audit log.
+func (p *ProductConsumer) auditWorker(ctx context.Context, id int, delivery amqp.Delivery) {
+	jobCtx := logger.With(ctx, logger.JobIDKey, delivery.MessageId, "worker", id)
+
+	var event models.AuditEvent
+	if err := json.Unmarshal(delivery.Body, &event); err != nil || event.OrgID == "" {
+		p.log.ErrorContext(jobCtx, "invalid audit event", "err", err)
+		_ = delivery.Reject(false)
+		return
+	}
+	jobCtx = logger.With(jobCtx, logger.OrgIDKey, event.OrgID, logger.RequestIDKey, event.RequestID)
+
+	if err := helpers.AppendAuditEvent(jobCtx, p.rdb, event); err != nil {
+		p.log.ErrorContext(jobCtx, "audit append failed", "err", err)
+		_ = delivery.Reject(true)
+		return
+	}
+
+	if err := delivery.Ack(false); err != nil {
+		p.log.ErrorContext(jobCtx, "ack failed", "err", err)
 	}
 }
 
-func (p *ProductConsumer) StartConsumer(workerPoolSize int, exchange, queueName, bindingKey, consumerTag string) error {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+// StartConsumer consumes the product enrichment queue. It returns once ctx
+// is cancelled and every received delivery has been settled, or when the
+// channel closes.
+func (p *ProductConsumer) StartConsumer(ctx context.Context, workerPoolSize int, exchange, queueName, bindingKey, consumerTag string) error {
+	return p.consume(ctx, p.worker, workerPoolSize, exchange, queueName, bindingKey, consumerTag)
+}
+
+// StartUpdateConsumer consumes the fast-path partial update queue.
+func (p *ProductConsumer) StartUpdateConsumer(ctx context.Context, workerPoolSize int, exchange, queueName, bindingKey, consumerTag string) error {
+	return p.consume(ctx, p.updateWorker, workerPoolSize, exchange, queueName, bindingKey, consumerTag)
+}
+
+// StartAuditConsumer consumes the audit event queue. A single worker keeps
+// events in publish order.
+func (p *ProductConsumer) StartAuditConsumer(ctx context.Context, exchange, queueName, bindingKey, consumerTag string) error {
+	return p.consume(ctx, p.auditWorker, 1, exchange, queueName, bindingKey, consumerTag)
+}
+
+// handler processes one delivery and settles it.
+type handler func(ctx context.Context, id int, delivery amqp.Delivery)
 
+func (p *ProductConsumer) consume(ctx context.Context, handle handler, workerPoolSize int, exchange, queueName, bindingKey, consumerTag string) error {
 	ch, err := p.CreateChannel(exchange, queueName, bindingKey, consumerTag)
 	if err != nil {
 		return errors.Wrap(err, "CreateChannel")
 	}
 	defer ch.Close()
 
+	chanClosed := ch.NotifyClose(make(chan *amqp.Error, 1))
+
 	deliveries, err := ch.Consume(
 		queueName,
 		consumerTag,
@@ -162,10 +333,20 @@ func (p *ProductConsumer) StartConsumer(workerPoolSize int, exchange, queueName,
 	jobs := make(chan amqp.Delivery, workerPoolSize*2)
 
 	// Start worker pool
-	for i := 0; i < workerPoolSize; i++ {
-		go p.worker(ctx, i, jobs)
+	qos := func(prefetch int) error {
+		return ch.Qos(prefetch, prefetchSize, prefetchGlobal)
+	}
+	workers := newWorkerPool(qos, jobs, func(id int, d amqp.Delivery) {
+		p.process(p.work, handle, queueName, id, d)
+	})
+	if err := workers.resize(workerPoolSize); err != nil {
+		close(jobs)
+		return err
 	}
 
+	p.setPool(queueName, workers)
+	defer p.setPool(queueName, nil)
+
 	// Consumer loop
 	go func() {
 		for d := range deliveries {
@@ -173,7 +354,31 @@ func (p *ProductConsumer) StartConsumer(workerPoolSize int, exchange, queueName,
 		}
 		close(jobs)
 	}()
-	chanErr := <-ch.NotifyClose(make(chan *amqp.Error))
 
-	return chanErr
+	select {
+	case <-ctx.Done():
+		// Cancelling closes deliveries once those already sent to us are
+		// drained. The channel stays open until workers have settled them.
+		if err := ch.Cancel(consumerTag, consumeNoWait); err != nil {
+			p.log.Warn("failed to cancel consumer", "queue", queueName, "err", err)
+		}
+		workers.wait()
+		p.log.Info("consumer stopped", "queue", queueName)
+		return nil
+	case chanErr := <-chanClosed:
+		if chanErr == nil {
+			return errors.New("channel closed")
+		}
+		return chanErr
+	}
+}
+
+// process runs handle under a consumer span that continues the publisher's
+// trace. The span ends, and the delivery's metrics are recorded, once the
+// handler has settled it.
+func (p *ProductConsumer) process(ctx context.Context, handle handler, queue string, id int, d amqp.Delivery) {
+	ctx, span := tracing.StartConsume(ctx, queue, d)
+	defer span.End()
+
+	handle(ctx, id, instrument(queue, d, span))
 }
@@ -0,0 +1,71 @@
+package mq
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/metrics"
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrument records a delivery's arrival at a worker and wraps its
+// acknowledger so the outcome, processing time and busy workers are
+// recorded when the worker settles it. The outcome is also set on span.
+func instrument(queue string, d amqp.Delivery, span trace.Span) amqp.Delivery {
+	if d.Redelivered {
+		metrics.AMQPRedeliveries.WithLabelValues(queue).Inc()
+	}
+	if !d.Timestamp.IsZero() {
+		metrics.AMQPQueueLag.WithLabelValues(queue).Observe(time.Since(d.Timestamp).Seconds())
+	}
+	metrics.WorkersBusy.WithLabelValues(queue).Inc()
+
+	d.Acknowledger = &settleRecorder{Acknowledger: d.Acknowledger, queue: queue, span: span, start: time.Now()}
+	return d
+}
+
+type settleRecorder struct {
+	amqp.Acknowledger
+	queue string
+	span  trace.Span
+	start time.Time
+	once  sync.Once
+}
+
+func (s *settleRecorder) Ack(tag uint64, multiple bool) error {
+	s.settled("ack")
+	return s.Acknowledger.Ack(tag, multiple)
+}
+
+func (s *settleRecorder) Nack(tag uint64, multiple bool, requeue bool) error {
+	s.settled(outcome(requeue))
+	return s.Acknowledger.Nack(tag, multiple, requeue)
+}
+
+func (s *settleRecorder) Reject(tag uint64, requeue bool) error {
+	s.settled(outcome(requeue))
+	return s.Acknowledger.Reject(tag, requeue)
+}
+
+func (s *settleRecorder) settled(outcome string) {
+	s.once.Do(func() {
+		metrics.WorkersBusy.WithLabelValues(s.queue).Dec()
+		metrics.AMQPProcessingDuration.WithLabelValues(s.queue).Observe(time.Since(s.start).Seconds())
+		metrics.AMQPMessagesConsumed.WithLabelValues(s.queue, outcome).Inc()
+
+		s.span.SetAttributes(attribute.String("messaging.outcome", outcome))
+		if outcome != "ack" {
+			s.span.SetStatus(codes.Error, outcome)
+		}
+	})
+}
+
+func outcome(requeue bool) string {
+	if requeue {
+		return "requeue"
+	}
+	return "reject"
+}
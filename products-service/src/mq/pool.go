@@ -0,0 +1,98 @@
+package mq
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/streadway/amqp"
+)
+
+// workerPool runs a queue's deliveries on a set of workers that can be
+// resized while it runs. The channel's prefetch follows the pool size so
+// every worker can hold a delivery.
+type workerPool struct {
+	// qos sets the channel's prefetch count.
+	qos  func(prefetch int) error
+	jobs <-chan amqp.Delivery
+	run  func(id int, d amqp.Delivery)
+
+	mu      sync.Mutex
+	quits   []chan struct{}
+	nextID  int
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+func newWorkerPool(qos func(prefetch int) error, jobs <-chan amqp.Delivery, run func(id int, d amqp.Delivery)) *workerPool {
+	return &workerPool{qos: qos, jobs: jobs, run: run}
+}
+
+// resize starts or stops workers until size are running. Stopped workers
+// finish their current delivery first.
+func (w *workerPool) resize(size int) error {
+	if size <= 0 {
+		return errors.Errorf("invalid worker pool size %d", size)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return errors.New("worker pool stopped")
+	}
+
+	for len(w.quits) < size {
+		quit := make(chan struct{})
+		w.quits = append(w.quits, quit)
+		w.wg.Add(1)
+		go w.work(w.nextID, quit)
+		w.nextID++
+	}
+	for len(w.quits) > size {
+		last := len(w.quits) - 1
+		close(w.quits[last])
+		w.quits = w.quits[:last]
+	}
+
+	if err := w.qos(size); err != nil {
+		return errors.Wrap(err, "Error ch.Qos")
+	}
+	return nil
+}
+
+func (w *workerPool) size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.quits)
+}
+
+// wait blocks until every worker has stopped, which they do once jobs is
+// closed and drained.
+func (w *workerPool) wait() {
+	w.mu.Lock()
+	w.stopped = true
+	w.mu.Unlock()
+
+	w.wg.Wait()
+}
+
+func (w *workerPool) work(id int, quit chan struct{}) {
+	defer w.wg.Done()
+	for {
+		// Prefer quitting over taking another delivery.
+		select {
+		case <-quit:
+			return
+		default:
+		}
+
+		select {
+		case <-quit:
+			return
+		case d, ok := <-w.jobs:
+			if !ok {
+				return
+			}
+			w.run(id, d)
+		}
+	}
+}
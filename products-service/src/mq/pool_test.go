@@ -0,0 +1,231 @@
+package mq
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// blockingRun counts deliveries in flight and holds each until released.
+type blockingRun struct {
+	started  chan int
+	release  chan struct{}
+	inFlight atomic.Int32
+	peak     atomic.Int32
+	done     atomic.Int32
+}
+
+func newBlockingRun() *blockingRun {
+	return &blockingRun{started: make(chan int, 100), release: make(chan struct{})}
+}
+
+func (b *blockingRun) run(id int, d amqp.Delivery) {
+	n := b.inFlight.Add(1)
+	for {
+		peak := b.peak.Load()
+		if n <= peak || b.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	b.started <- id
+	<-b.release
+	b.inFlight.Add(-1)
+	b.done.Add(1)
+}
+
+// waitStarted waits for n deliveries to start running.
+func (b *blockingRun) waitStarted(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-b.started:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("only %d of %d deliveries started", i, n)
+		}
+	}
+}
+
+// assertNoneStart checks that no further delivery starts for a while.
+func (b *blockingRun) assertNoneStart(t *testing.T) {
+	t.Helper()
+	select {
+	case id := <-b.started:
+		t.Fatalf("worker %d started a delivery beyond the pool size", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+type qosRecorder struct {
+	mu    sync.Mutex
+	sizes []int
+	err   error
+}
+
+func (q *qosRecorder) qos(prefetch int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.sizes = append(q.sizes, prefetch)
+	return q.err
+}
+
+func (q *qosRecorder) recorded() []int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.Clone(q.sizes)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func enqueue(jobs chan<- amqp.Delivery, n int) {
+	for i := 0; i < n; i++ {
+		jobs <- amqp.Delivery{}
+	}
+}
+
+func TestWorkerPoolGrowWithJobsInFlight(t *testing.T) {
+	jobs := make(chan amqp.Delivery, 10)
+	run := newBlockingRun()
+	qos := &qosRecorder{}
+	pool := newWorkerPool(qos.qos, jobs, run.run)
+
+	if err := pool.resize(2); err != nil {
+		t.Fatalf("resize(2): %v", err)
+	}
+	enqueue(jobs, 4)
+	run.waitStarted(t, 2)
+	run.assertNoneStart(t)
+
+	// The new workers pick up the queued deliveries while the first two
+	// are still busy.
+	if err := pool.resize(4); err != nil {
+		t.Fatalf("resize(4): %v", err)
+	}
+	run.waitStarted(t, 2)
+	if got := run.inFlight.Load(); got != 4 {
+		t.Errorf("in flight = %d, want 4", got)
+	}
+	if got := pool.size(); got != 4 {
+		t.Errorf("size = %d, want 4", got)
+	}
+
+	close(run.release)
+	close(jobs)
+	pool.wait()
+
+	if got := run.done.Load(); got != 4 {
+		t.Errorf("done = %d, want 4", got)
+	}
+	if got := qos.recorded(); !slices.Equal(got, []int{2, 4}) {
+		t.Errorf("prefetch = %v, want [2 4]", got)
+	}
+}
+
+func TestWorkerPoolShrinkWithJobsInFlight(t *testing.T) {
+	jobs := make(chan amqp.Delivery, 10)
+	run := newBlockingRun()
+	qos := &qosRecorder{}
+	pool := newWorkerPool(qos.qos, jobs, run.run)
+
+	if err := pool.resize(3); err != nil {
+		t.Fatalf("resize(3): %v", err)
+	}
+	enqueue(jobs, 3)
+	run.waitStarted(t, 3)
+
+	// Shrinking does not wait for or interrupt the busy workers.
+	if err := pool.resize(1); err != nil {
+		t.Fatalf("resize(1): %v", err)
+	}
+	if got := pool.size(); got != 1 {
+		t.Errorf("size = %d, want 1", got)
+	}
+	if got := run.inFlight.Load(); got != 3 {
+		t.Errorf("in flight after shrink = %d, want 3", got)
+	}
+
+	for i := 0; i < 3; i++ {
+		run.release <- struct{}{}
+	}
+	waitFor(t, func() bool { return run.done.Load() == 3 })
+
+	// Only the remaining worker takes new deliveries.
+	enqueue(jobs, 3)
+	for i := 0; i < 3; i++ {
+		run.waitStarted(t, 1)
+		run.assertNoneStart(t)
+		run.release <- struct{}{}
+	}
+
+	close(jobs)
+	pool.wait()
+
+	if got := run.done.Load(); got != 6 {
+		t.Errorf("done = %d, want 6", got)
+	}
+	if got := qos.recorded(); !slices.Equal(got, []int{3, 1}) {
+		t.Errorf("prefetch = %v, want [3 1]", got)
+	}
+}
+
+func TestWorkerPoolShrinkLimitsConcurrency(t *testing.T) {
+	jobs := make(chan amqp.Delivery, 10)
+	run := newBlockingRun()
+	pool := newWorkerPool((&qosRecorder{}).qos, jobs, run.run)
+
+	if err := pool.resize(4); err != nil {
+		t.Fatalf("resize(4): %v", err)
+	}
+	if err := pool.resize(2); err != nil {
+		t.Fatalf("resize(2): %v", err)
+	}
+
+	enqueue(jobs, 5)
+	run.waitStarted(t, 2)
+	run.assertNoneStart(t)
+
+	close(run.release)
+	close(jobs)
+	pool.wait()
+
+	if got := run.peak.Load(); got != 2 {
+		t.Errorf("peak in flight = %d, want 2", got)
+	}
+	if got := run.done.Load(); got != 5 {
+		t.Errorf("done = %d, want 5", got)
+	}
+}
+
+func TestWorkerPoolResizeErrors(t *testing.T) {
+	jobs := make(chan amqp.Delivery)
+	qos := &qosRecorder{}
+	pool := newWorkerPool(qos.qos, jobs, func(int, amqp.Delivery) {})
+
+	if err := pool.resize(0); err == nil {
+		t.Error("resize(0) should fail")
+	}
+
+	qos.err = errors.New("channel closed")
+	if err := pool.resize(2); !errors.Is(err, qos.err) {
+		t.Errorf("resize with failing qos = %v, want %v", err, qos.err)
+	}
+
+	close(jobs)
+	pool.wait()
+	if err := pool.resize(1); err == nil {
+		t.Error("resize after wait should fail")
+	}
+}
@@ -1,9 +1,12 @@
 package mq
 
 import (
+	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/Adityadangi14/ecomm_ai/config"
+	"github.com/Adityadangi14/ecomm_ai/pkg/tracing"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/llm"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -12,7 +15,9 @@ import (
 
 type ProductPublisher interface {
 	SetupExchangeAndQueue(exchange, queueName, bindingKey, consumerTag string) error
-	Publish(body []byte, contentType string) error
+	Publish(ctx context.Context, body []byte, contentType string) error
+	PublishUpdate(ctx context.Context, body []byte) error
+	PublishAudit(ctx context.Context, body []byte) error
 	CloseChan() error
 }
 
@@ -22,6 +27,7 @@ type Productpublisher struct {
 	amqpChan *amqp.Channel
 	cfg      *config.Config
 	Aiclient llm.Aiclient
+	closed   atomic.Bool
 }
 
 func NewProductsPublisher(mqConn *amqp.Connection, cfg *config.Config, aiClient llm.Aiclient) (*Productpublisher, error) {
@@ -30,7 +36,20 @@ func NewProductsPublisher(mqConn *amqp.Connection, cfg *config.Config, aiClient
 	if err != nil {
 		return nil, err
 	}
-	return &Productpublisher{amqpChan: amqpChan, cfg: cfg, Aiclient: aiClient}, nil
+	p := &Productpublisher{amqpChan: amqpChan, cfg: cfg, Aiclient: aiClient}
+
+	closed := amqpChan.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		<-closed
+		p.closed.Store(true)
+	}()
+
+	return p, nil
+}
+
+// ChannelOpen reports whether the publishing channel is still usable.
+func (p *Productpublisher) ChannelOpen() bool {
+	return !p.closed.Load()
 }
 
 func (p *Productpublisher) SetupExchangeAndQueue(exchange, queueName, bindingKey, consumerTag string) error {
@@ -79,20 +98,43 @@ func (p *Productpublisher) CloseChan() error {
 	return nil
 }
 
-func (p *Productpublisher) Publish(body []byte, contentType string) error {
+func (p *Productpublisher) Publish(ctx context.Context, body []byte, contentType string) error {
+	return p.publish(ctx, p.cfg.RabbitMQ.Exchange, p.cfg.RabbitMQ.RoutingKey, body, contentType)
+}
+
+// PublishUpdate sends a partial product update to the fast-path queue.
+func (p *Productpublisher) PublishUpdate(ctx context.Context, body []byte) error {
+	return p.publish(ctx, p.cfg.RabbitMQ.UpdatesExchange, p.cfg.RabbitMQ.UpdatesRoutingKey, body, "application/json")
+}
+
+// PublishAudit sends an audit event to the audit exchange.
+func (p *Productpublisher) PublishAudit(ctx context.Context, body []byte) error {
+	return p.publish(ctx, p.cfg.RabbitMQ.AuditExchange, p.cfg.RabbitMQ.AuditRoutingKey, body, "application/json")
+}
+
+// publish sends body with the trace context of ctx in its headers, so the
+// consumer's span joins the publisher's trace.
+func (p *Productpublisher) publish(ctx context.Context, exchange, routingKey string, body []byte, contentType string) error {
+	messageID := uuid.New().String()
+
+	_, span, headers := tracing.StartPublish(ctx, exchange, routingKey, messageID)
+	defer span.End()
+
 	if err := p.amqpChan.Publish(
-		p.cfg.RabbitMQ.Exchange,
-		p.cfg.RabbitMQ.RoutingKey,
+		exchange,
+		routingKey,
 		publishMandatory,
 		publishImmediate,
 		amqp.Publishing{
+			Headers:      headers,
 			ContentType:  contentType,
 			DeliveryMode: amqp.Persistent,
-			MessageId:    uuid.New().String(),
+			MessageId:    messageID,
 			Timestamp:    time.Now(),
 			Body:         body,
 		},
 	); err != nil {
+		tracing.Fail(span, err)
 		return errors.Wrap(err, "ch.Publish")
 	}
 	return nil
@@ -0,0 +1,213 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/config"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/auth"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// ExceededError is returned when a request is over one of the org's plan
+// limits.
+type ExceededError struct {
+	Limit      string
+	RetryAfter time.Duration
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s quota exceeded, retry after %v", e.Limit, e.RetryAfter.Round(time.Second))
+}
+
+// Limiter enforces the per-plan quotas of the runtime config, so changed
+// limits apply to the next request.
+type Limiter interface {
+	// ChatLimit is middleware for chat requests: per-org and per-user rates
+	// and the monthly token budget.
+	ChatLimit() fiber.Handler
+	// UploadLimit is middleware refusing uploads that would not fit in the
+	// org's daily product limit.
+	UploadLimit() fiber.Handler
+	// AllowIngest counts one product against the org's daily limit and
+	// checks the monthly token budget before enrichment.
+	AllowIngest(ctx context.Context, orgID string) error
+	Plan(ctx context.Context, orgID string) config.PlanQuota
+}
+
+type limiter struct {
+	rdb     *redis.Client
+	runtime *config.Runtime
+	log     *slog.Logger
+}
+
+func NewLimiter(rdb *redis.Client, runtime *config.Runtime, log *slog.Logger) Limiter {
+	return &limiter{rdb: rdb, runtime: runtime, log: log}
+}
+
+func (l *limiter) Plan(ctx context.Context, orgID string) config.PlanQuota {
+	name, err := helpers.GetOrgPlan(ctx, l.rdb, orgID)
+	if err != nil {
+		l.log.WarnContext(ctx, "failed to load org plan, using default", "orgId", orgID, "err", err)
+	}
+	return l.runtime.Current().Quotas.Plan(name)
+}
+
+func (l *limiter) ChatLimit() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var params models.AiQueryParams
+		if err := c.BodyParser(&params); err != nil {
+			return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("failed to parse body: %v", err))
+		}
+
+		if orgID := auth.OrgID(c); orgID != "" {
+			params.OrgID = orgID
+		}
+		// Only a shopper token names the user. Other callers choose the
+		// userId in the body, so their per-user rate is kept per caller.
+		subject := auth.UserID(c)
+		if subject == "" {
+			subject = auth.Principal(c)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+		defer cancel()
+
+		requestID, _ := c.Locals("requestid").(string)
+		err := l.allowChat(ctx, params.OrgID, subject, requestID)
+		return l.handle(c, err)
+	}
+}
+
+func (l *limiter) UploadLimit() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var products models.ProductsModel
+		if err := c.BodyParser(&products); err != nil {
+			return utils.Fail(c, fiber.StatusBadRequest, fmt.Sprintf("failed to parse body: %v", err))
+		}
+
+		perOrg := map[string]int{}
+		for _, p := range products.Products {
+			if orgID := auth.OrgID(c); orgID != "" {
+				p.OrgID = orgID
+			}
+			perOrg[p.OrgID]++
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+		defer cancel()
+
+		for orgID, n := range perOrg {
+			if err := l.fitsDailyUploads(ctx, orgID, n); err != nil {
+				return l.handle(c, err)
+			}
+		}
+		return c.Next()
+	}
+}
+
+func (l *limiter) AllowIngest(ctx context.Context, orgID string) error {
+	plan := l.Plan(ctx, orgID)
+
+	if plan.UploadsPerDay > 0 {
+		ok, err := helpers.ReserveDailyUploads(ctx, l.rdb, orgID, 1, plan.UploadsPerDay)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return &ExceededError{Limit: "daily upload", RetryAfter: untilNextDay()}
+		}
+	}
+
+	return l.checkMonthlyTokens(ctx, orgID, plan)
+}
+
+func (l *limiter) allowChat(ctx context.Context, orgID string, subject string, requestID string) error {
+	plan := l.Plan(ctx, orgID)
+
+	if plan.ChatPerMinute > 0 {
+		ok, wait, err := helpers.AllowSlidingWindow(ctx, l.rdb, helpers.GetChatRateKey(orgID), plan.ChatPerMinute, time.Minute, requestID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return &ExceededError{Limit: "org chat rate", RetryAfter: wait}
+		}
+	}
+
+	if plan.ChatPerMinutePerUser > 0 && subject != "" {
+		ok, wait, err := helpers.AllowSlidingWindow(ctx, l.rdb, helpers.GetUserChatRateKey(orgID, subject), plan.ChatPerMinutePerUser, time.Minute, requestID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return &ExceededError{Limit: "user chat rate", RetryAfter: wait}
+		}
+	}
+
+	return l.checkMonthlyTokens(ctx, orgID, plan)
+}
+
+func (l *limiter) fitsDailyUploads(ctx context.Context, orgID string, n int) error {
+	plan := l.Plan(ctx, orgID)
+	if plan.UploadsPerDay <= 0 {
+		return nil
+	}
+
+	used, err := helpers.GetDailyUploads(ctx, l.rdb, orgID)
+	if err != nil {
+		return err
+	}
+	if used+n > plan.UploadsPerDay {
+		return &ExceededError{Limit: "daily upload", RetryAfter: untilNextDay()}
+	}
+	return nil
+}
+
+func (l *limiter) checkMonthlyTokens(ctx context.Context, orgID string, plan config.PlanQuota) error {
+	if plan.MonthlyTokens <= 0 {
+		return nil
+	}
+
+	used, err := helpers.GetMonthlyTokens(ctx, l.rdb, orgID)
+	if err != nil {
+		return err
+	}
+	if used >= plan.MonthlyTokens {
+		return &ExceededError{Limit: "monthly token", RetryAfter: untilNextMonth()}
+	}
+	return nil
+}
+
+// handle turns a quota check into a response. Redis failures let the request
+// through rather than taking the service down with the limiter.
+func (l *limiter) handle(c *fiber.Ctx, err error) error {
+	var exceeded *ExceededError
+	if errors.As(err, &exceeded) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(exceeded.RetryAfter.Seconds()))))
+		return utils.Fail(c, fiber.StatusTooManyRequests, exceeded.Error())
+	}
+	if err != nil {
+		l.log.WarnContext(auth.LogContext(c), "quota check failed, allowing request", "err", err)
+	}
+	return c.Next()
+}
+
+func untilNextDay() time.Duration {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC).Sub(now)
+}
+
+func untilNextMonth() time.Duration {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC).Sub(now)
+}
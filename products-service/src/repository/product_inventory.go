@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+)
+
+var skuKeyRe = regexp.MustCompile(`^(attr_\d+_)skuId$`)
+
+// UpdateAvailability merges variant availability and stock into a stored
+// product.
+func (p *prodRepo) UpdateAvailability(ctx context.Context, orgID string, productID string, updates []models.SkuStockUpdate) error {
+	skus := make([]models.SkuUpdate, 0, len(updates))
+	for _, u := range updates {
+		availability := u.Availability
+		skus = append(skus, models.SkuUpdate{SkuID: u.SkuID, Availability: &availability, Stock: u.Stock})
+	}
+	return p.PatchVariants(ctx, models.ProductUpdate{OrgID: orgID, ProductID: productID, Skus: skus})
+}
+
+// PatchVariants merges partial variant fields into a stored product without
+// touching search_text, so no re-enrichment is needed. The product level
+// minPrice and availability are recomputed from all variants. Writes to a
+// product are serialized so concurrent patches do not overwrite each other's
+// recomputed fields.
+func (p *prodRepo) PatchVariants(ctx context.Context, update models.ProductUpdate) error {
+	unlock, err := p.lock(ctx, update.OrgID, update.ProductID)
+	if err != nil {
+		return fmt.Errorf("failed to lock product %v", err)
+	}
+	defer unlock()
+
+	copies, err := p.productCopies(ctx, update.OrgID, update.ProductID)
+	if err != nil {
+		return err
+	}
+	if len(copies) == 0 {
+		return ErrProductNotFound
+	}
+
+	for _, product := range copies {
+		props, err := variantPatch(product, update)
+		if err != nil {
+			return err
+		}
+
+		qctx, done := startQuery(ctx, "patch")
+		err = p.WDB.DB.Data().Updater().
+			WithMerge().
+			WithClassName("Product").
+			WithID(product.ID).
+			WithProperties(props).
+			Do(qctx)
+		done(err)
+		if err != nil {
+			return fmt.Errorf("failed to patch product %v", err)
+		}
+	}
+	return nil
+}
+
+// variantPatch returns the properties that apply update to product.
+func variantPatch(product models.ProductHit, update models.ProductUpdate) (map[string]any, error) {
+	prefixes := make(map[string]string)
+	for key, val := range product.Properties {
+		m := skuKeyRe.FindStringSubmatch(key)
+		if m == nil {
+			continue
+		}
+		if sku, ok := val.(string); ok && sku != "" {
+			prefixes[sku] = m[1]
+		}
+	}
+
+	props := map[string]any{}
+	for _, u := range update.Skus {
+		prefix, ok := prefixes[u.SkuID]
+		if !ok {
+			return nil, fmt.Errorf("sku %s not found on product %s", u.SkuID, update.ProductID)
+		}
+		if u.Price != nil {
+			props[prefix+"price"] = *u.Price
+		}
+		if u.SalePrice != nil {
+			props[prefix+"salePrice"] = *u.SalePrice
+		}
+		if u.Availability != nil {
+			props[prefix+"availability"] = *u.Availability
+		}
+		if u.Stock != nil {
+			props[prefix+"stock"] = *u.Stock
+		}
+		if u.OnClickURL != nil {
+			props[prefix+"onClickUrl"] = *u.OnClickURL
+		}
+	}
+
+	// current reads the patched value if present, else the stored one.
+	current := func(key string) string {
+		if v, ok := props[key].(string); ok {
+			return v
+		}
+		v, _ := product.Properties[key].(string)
+		return v
+	}
+
+	prices := make([]string, 0, len(prefixes))
+	availabilities := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		prices = append(prices, models.EffectivePrice(current(prefix+"price"), current(prefix+"salePrice")))
+		availabilities = append(availabilities, current(prefix+"availability"))
+	}
+	if minPrice, ok := models.LowestPrice(prices); ok {
+		props["minPrice"] = minPrice
+	}
+	if availability := models.BestAvailability(availabilities); availability != "" {
+		props["availability"] = availability
+	}
+
+	return props, nil
+}
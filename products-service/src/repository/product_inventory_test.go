@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+)
+
+// variantProduct is a stored product with two variants of the given
+// availability.
+func variantProduct(id string, productID string, availability1 string, availability2 string) fakeObject {
+	return product(id, "acme", productID, map[string]any{
+		"attr_1_skuId":        "sku-1",
+		"attr_1_price":        "20",
+		"attr_1_availability": availability1,
+		"attr_2_skuId":        "sku-2",
+		"attr_2_price":        "30",
+		"attr_2_availability": availability2,
+		"availability":        models.BestAvailability([]string{availability1, availability2}),
+	})
+}
+
+func TestSaveProductReplacesEarlierUploads(t *testing.T) {
+	fake := newFakeWeaviate(
+		product("00000000-0000-0000-0000-000000000001", "acme", "prod-1", map[string]any{"name": "old"}),
+		product("00000000-0000-0000-0000-000000000002", "acme", "prod-1", map[string]any{"name": "older"}),
+		product("00000000-0000-0000-0000-000000000003", "acme", "prod-1-b", nil),
+	)
+	repo := newTestRepo(t, fake)
+
+	for _, name := range []string{"new", "newer"} {
+		err := repo.SaveProduct(context.Background(), map[string]any{"orgId": "acme", "productId": "prod-1", "name": name})
+		if err != nil {
+			t.Fatalf("SaveProduct: %v", err)
+		}
+	}
+
+	copies, err := repo.productCopies(context.Background(), "acme", "prod-1")
+	if err != nil {
+		t.Fatalf("productCopies: %v", err)
+	}
+	if len(copies) != 1 {
+		t.Fatalf("got %d stored copies, want 1", len(copies))
+	}
+	if copies[0].ID != productUUID("acme", "prod-1") || copies[0].Properties["name"] != "newer" {
+		t.Errorf("got %s named %v, want %s named newer", copies[0].ID, copies[0].Properties["name"], productUUID("acme", "prod-1"))
+	}
+
+	if _, ok := fake.object("00000000-0000-0000-0000-000000000003"); !ok {
+		t.Error("product with an overlapping ID was deleted")
+	}
+}
+
+func TestProductUUID(t *testing.T) {
+	if productUUID("acme", "prod-1") != productUUID("acme", "prod-1") {
+		t.Error("product IDs are not stable")
+	}
+	if productUUID("acme", "prod-1") == productUUID("acme-eu", "prod-1") {
+		t.Error("orgs share product IDs")
+	}
+	if productUUID("ac", "me-prod-1") == productUUID("acme", "-prod-1") {
+		t.Error("org and product ID are not separated")
+	}
+}
+
+func TestPatchVariantsUpdatesEveryCopy(t *testing.T) {
+	fake := newFakeWeaviate(
+		variantProduct("00000000-0000-0000-0000-000000000001", "prod-1", models.AvailabilityInStock, models.AvailabilityInStock),
+		variantProduct("00000000-0000-0000-0000-000000000002", "prod-1", models.AvailabilityInStock, models.AvailabilityInStock),
+		variantProduct("00000000-0000-0000-0000-000000000003", "prod-1-b", models.AvailabilityInStock, models.AvailabilityInStock),
+	)
+	repo := newTestRepo(t, fake)
+
+	price := "10"
+	err := repo.PatchVariants(context.Background(), models.ProductUpdate{
+		OrgID:     "acme",
+		ProductID: "prod-1",
+		Skus:      []models.SkuUpdate{{SkuID: "sku-2", Price: &price}},
+	})
+	if err != nil {
+		t.Fatalf("PatchVariants: %v", err)
+	}
+
+	for _, id := range []string{"00000000-0000-0000-0000-000000000001", "00000000-0000-0000-0000-000000000002"} {
+		o, _ := fake.object(id)
+		if o.props["attr_2_price"] != "10" || o.props["minPrice"] != float64(10) {
+			t.Errorf("copy %s: got price %v and minPrice %v, want 10", id, o.props["attr_2_price"], o.props["minPrice"])
+		}
+	}
+	if o, _ := fake.object("00000000-0000-0000-0000-000000000003"); o.props["attr_2_price"] != "30" {
+		t.Errorf("product with an overlapping ID was patched to %v", o.props["attr_2_price"])
+	}
+
+	err = repo.PatchVariants(context.Background(), models.ProductUpdate{OrgID: "acme", ProductID: "prod", Skus: []models.SkuUpdate{{SkuID: "sku-1", Price: &price}}})
+	if err != ErrProductNotFound {
+		t.Errorf("patching a partial ID: err = %v, want ErrProductNotFound", err)
+	}
+}
+
+func TestPatchVariantsConcurrentUpdates(t *testing.T) {
+	for i := range 20 {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			fake := newFakeWeaviate(variantProduct("00000000-0000-0000-0000-000000000001", "prod-1", models.AvailabilityInStock, models.AvailabilityInStock))
+			repo := newTestRepo(t, fake)
+
+			// Each patch alone leaves the other variant in stock; only both
+			// together take the product out of stock.
+			var wg sync.WaitGroup
+			for _, sku := range []string{"sku-1", "sku-2"} {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					availability := models.AvailabilityOutOfStock
+					err := repo.PatchVariants(context.Background(), models.ProductUpdate{
+						OrgID:     "acme",
+						ProductID: "prod-1",
+						Skus:      []models.SkuUpdate{{SkuID: sku, Availability: &availability}},
+					})
+					if err != nil {
+						t.Errorf("PatchVariants %s: %v", sku, err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			o, _ := fake.object("00000000-0000-0000-0000-000000000001")
+			if o.props["availability"] != models.AvailabilityOutOfStock {
+				t.Errorf("availability = %v, want %s", o.props["availability"], models.AvailabilityOutOfStock)
+			}
+		})
+	}
+}
@@ -3,96 +3,228 @@ package repository
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/Adityadangi14/ecomm_ai/config"
 	"github.com/Adityadangi14/ecomm_ai/pkg/WDB"
-	"github.com/weaviate/weaviate-go-client/v4/weaviate/filters"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/helpers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/metrics"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/go-openapi/strfmt"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 	weaviategraphql "github.com/weaviate/weaviate-go-client/v4/weaviate/graphql"
+	weaviatemodels "github.com/weaviate/weaviate/entities/models"
 )
 
 type ProductRepository interface {
 	SaveProduct(ctx context.Context, data map[string]any) error
-	NearSearchProducts(ctx context.Context, query string, orgID string) ([]map[string]any, error)
+	NearSearchProducts(ctx context.Context, query string, params models.SearchParams) ([]models.ProductHit, error)
+	SearchProducts(ctx context.Context, params models.SearchParams) (*models.SearchResult, error)
+	SimilarProducts(ctx context.Context, params models.SimilarParams) ([]models.ProductHit, error)
+	GetProduct(ctx context.Context, orgID string, productID string) (*models.ProductHit, error)
+	UpdateAvailability(ctx context.Context, orgID string, productID string, updates []models.SkuStockUpdate) error
+	PatchVariants(ctx context.Context, update models.ProductUpdate) error
 	DeleteAllProducts(ctx context.Context) error
 }
 
+var productFields = []weaviategraphql.Field{
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_associateValue"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_associateValueName"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_attributeName"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_image"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_onClickUrl"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_price"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_skuId"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_value"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_associateValue"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_associateValueName"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_attributeName"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_image"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_onClickUrl"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_price"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_skuId"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_value"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "brand"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "description"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "name"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "priceCurrency"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "productId"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "minPrice"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "availability"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_availability"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_stock"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_availability"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_stock"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_salePrice"}),
+	weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_salePrice"}),
+}
+
 type prodRepo struct {
-	WDB *WDB.WDB
+	WDB     *WDB.WDB
+	rdb     *redis.Client
+	runtime *config.Runtime
+	log     *slog.Logger
+	// lock serializes writes to one product across service instances.
+	lock func(ctx context.Context, orgID string, productID string) (unlock func(), err error)
 }
 
-func NewProductRepository(wdb *WDB.WDB) ProductRepository {
-	return &prodRepo{WDB: wdb}
+// productLockTTL bounds how long a product write may hold its lock, and how
+// long another write waits for it.
+const productLockTTL = 30 * time.Second
+
+// maxProductCopies bounds how many stored objects of one product are loaded.
+// Only uploads from before product IDs were derived left more than one.
+const maxProductCopies = 100
+
+// productNamespace seeds the derived object IDs of products.
+var productNamespace = uuid.MustParse("6f1c2b0e-7d4a-4c1e-9b8a-3e5f0d2c7a91")
+
+func NewProductRepository(wdb *WDB.WDB, rdb *redis.Client, runtime *config.Runtime, log *slog.Logger) ProductRepository {
+	return &prodRepo{WDB: wdb, rdb: rdb, runtime: runtime, log: log, lock: func(ctx context.Context, orgID string, productID string) (func(), error) {
+		lockCtx, cancel := context.WithTimeout(ctx, productLockTTL)
+		defer cancel()
+		return helpers.LockProduct(lockCtx, rdb, orgID, productID, productLockTTL)
+	}}
 }
 
+// productUUID is the object ID of a product, derived from its org and
+// productId so a re-upload replaces the stored product.
+func productUUID(orgID string, productID string) string {
+	return uuid.NewSHA1(productNamespace, []byte(orgID+"\x00"+productID)).String()
+}
+
+// SaveProduct stores a product under its derived ID, replacing any earlier
+// upload. Copies left by uploads from before IDs were derived are deleted.
 func (p *prodRepo) SaveProduct(ctx context.Context, data map[string]any) error {
-	_, err := p.WDB.DB.Data().Creator().WithClassName("Product").WithProperties(data).Do(ctx)
+	orgID, _ := data["orgId"].(string)
+	productID, _ := data["productId"].(string)
+	data["orgKey"] = orgID
+	data["productKey"] = productID
+
+	unlock, err := p.lock(ctx, orgID, productID)
+	if err != nil {
+		return fmt.Errorf("failed to lock product %v", err)
+	}
+	defer unlock()
+
+	id := productUUID(orgID, productID)
+
+	// Batch imports overwrite an object with the same ID.
+	qctx, done := startQuery(ctx, "save")
+	resp, err := p.WDB.DB.Batch().ObjectsBatcher().
+		WithObjects(&weaviatemodels.Object{Class: "Product", ID: strfmt.UUID(id), Properties: data}).
+		Do(qctx)
+	if err == nil {
+		err = batchError(resp)
+	}
+	done(err)
+	if err != nil {
+		return fmt.Errorf("failed to save product %v", err)
+	}
+
+	copies, err := p.productCopies(ctx, orgID, productID)
 	if err != nil {
 		return err
 	}
+	for _, c := range copies {
+		if c.ID == id {
+			continue
+		}
+		qctx, done := startQuery(ctx, "delete_copy")
+		err := p.WDB.DB.Data().Deleter().WithClassName("Product").WithID(c.ID).Do(qctx)
+		done(err)
+		if err != nil {
+			return fmt.Errorf("failed to delete copy %s of product %v", c.ID, err)
+		}
+	}
 	return nil
 }
 
-func (p *prodRepo) NearSearchProducts(ctx context.Context, query string, orgID string) ([]map[string]any, error) {
-	hybrid := p.WDB.DB.GraphQL().HybridArgumentBuilder().
-		WithQuery(query).
-		WithAlpha(0.8)
-	whereFilter := filters.Where().
-		WithPath([]string{"orgId"}).
-		WithOperator(filters.Equal).
-		WithValueText(orgID)
+func batchError(resp []weaviatemodels.ObjectsGetResponse) error {
+	for _, r := range resp {
+		if r.Result != nil && r.Result.Errors != nil && len(r.Result.Errors.Error) > 0 {
+			return fmt.Errorf("%s", r.Result.Errors.Error[0].Message)
+		}
+	}
+	return nil
+}
+
+// NearSearchProducts runs the org's hybrid search narrowed by the structured
+// filters in params. A limit of 0 uses the org search settings limit.
+func (p *prodRepo) NearSearchProducts(ctx context.Context, query string, params models.SearchParams) ([]models.ProductHit, error) {
+	settings := p.searchSettings(ctx, params.OrgID)
+	limit := params.Limit
+	if limit <= 0 {
+		limit = settings.Limit
+	}
+
+	whereFilter := buildProductFilter(params)
 
-	resp, err := p.WDB.DB.GraphQL().Get().
+	fields := append([]weaviategraphql.Field{
+		{Name: "_additional", Fields: []weaviategraphql.Field{{Name: "id"}, {Name: "score"}}},
+	}, productFields...)
+
+	get := p.WDB.DB.GraphQL().Get().
 		WithClassName("Product").
-		WithFields(
-			weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_associateValue"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_associateValueName"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_attributeName"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_image"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_onClickUrl"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_price"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_skuId"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "attr_1_value"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_associateValue"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_associateValueName"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_attributeName"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_image"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_onClickUrl"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_price"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "attr_2_value"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "brand"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "description"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "name"}),
-			weaviategraphql.Field(weaviategraphql.Field{Name: "priceCurrency"}),
-		).
-		WithLimit(10).
-		WithWhere(whereFilter).
-		WithHybrid(hybrid).
-		Do(context.Background())
+		WithFields(fields...).
+		WithLimit(limit).
+		WithWhere(whereFilter)
+
+	qctx, done := startQuery(ctx, "hybrid_search")
+	resp, err := p.withHybrid(get, query, settings).Do(qctx)
+	done(err)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get products %v", err)
 	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("failed to get products %v", resp.Errors[0].Message)
+	}
+
+	rawProducts, err := classResults(resp.Data, "Get")
+	if err != nil {
+		return nil, err
+	}
+	metrics.ObserveResults("hybrid_search", len(rawProducts))
+
+	return models.RankByAvailability(toProductHits(rawProducts), params.InStockOnly), nil
 
-	var products []map[string]any
+}
 
-	getMap, ok := resp.Data["Get"].(map[string]any)
-	if !ok {
-		return nil, fmt.Errorf("invalid Get response format")
+// searchSettings loads the org's hybrid search settings, falling back to the
+// defaults if Redis is unavailable so search keeps working.
+func (p *prodRepo) searchSettings(ctx context.Context, orgID string) models.SearchSettings {
+	settings, err := helpers.GetSearchSettings(ctx, p.rdb, orgID, models.DefaultSearchSettings(p.runtime.Current().Search))
+	if err != nil {
+		p.log.WarnContext(ctx, "failed to load search settings, using defaults", "orgId", orgID, "err", err)
 	}
+	return settings
+}
 
-	fmt.Println("near search products", products)
-	rawProducts, ok := getMap["Product"].([]any)
-	if !ok {
-		return nil, fmt.Errorf("invalid Product result format")
+func (p *prodRepo) withHybrid(get *weaviategraphql.GetBuilder, query string, settings models.SearchSettings) *weaviategraphql.GetBuilder {
+	hybrid := p.WDB.DB.GraphQL().HybridArgumentBuilder().
+		WithQuery(query).
+		WithAlpha(settings.Alpha)
+
+	if len(settings.Properties) > 0 {
+		hybrid = hybrid.WithProperties(settings.Properties)
 	}
 
-	for _, p := range rawProducts {
-		if productMap, ok := p.(map[string]any); ok {
-			products = append(products, productMap)
-		}
+	if settings.FusionType == models.FusionRelativeScore {
+		hybrid = hybrid.WithFusionType(weaviategraphql.RelativeScore)
+	} else {
+		hybrid = hybrid.WithFusionType(weaviategraphql.Ranked)
 	}
 
-	return products, nil
+	get = get.WithHybrid(hybrid)
+
+	if settings.Autocut > 0 {
+		get = get.WithAutocut(settings.Autocut)
+	}
 
+	return get
 }
 
 func (p *prodRepo) DeleteAllProducts(ctx context.Context) error {
@@ -0,0 +1,307 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/metrics"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/filters"
+	weaviategraphql "github.com/weaviate/weaviate-go-client/v4/weaviate/graphql"
+	weaviatemodels "github.com/weaviate/weaviate/entities/models"
+)
+
+// facetProperties are the properties aggregated into facet counts for search.
+var facetProperties = []string{"brand", "priceCurrency", "attr_1_value", "attr_2_value"}
+
+// facetObjectLimit bounds how many top results of a free text query feed
+// facet counts.
+const facetObjectLimit = 200
+
+func (p *prodRepo) SearchProducts(ctx context.Context, params models.SearchParams) (*models.SearchResult, error) {
+	settings := p.searchSettings(ctx, params.OrgID)
+	if params.Limit <= 0 {
+		params.Limit = settings.Limit
+	}
+
+	where := buildProductFilter(params)
+
+	fields := append([]weaviategraphql.Field{
+		{Name: "_additional", Fields: []weaviategraphql.Field{{Name: "id"}, {Name: "score"}}},
+	}, productFields...)
+
+	get := p.WDB.DB.GraphQL().Get().
+		WithClassName("Product").
+		WithFields(fields...).
+		WithWhere(where).
+		WithLimit(params.Limit).
+		WithOffset(params.Offset)
+
+	if params.Query != "" {
+		get = p.withHybrid(get, params.Query, settings)
+	}
+
+	qctx, done := startQuery(ctx, "search")
+	resp, err := get.Do(qctx)
+	done(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search products %v", err)
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("failed to search products %v", resp.Errors[0].Message)
+	}
+
+	rawProducts, err := classResults(resp.Data, "Get")
+	if err != nil {
+		return nil, err
+	}
+	metrics.ObserveResults("search", len(rawProducts))
+
+	result := &models.SearchResult{Products: models.RankByAvailability(toProductHits(rawProducts), params.InStockOnly)}
+
+	if len(rawProducts) == params.Limit {
+		result.NextCursor = EncodeCursor(params.Offset + params.Limit)
+	}
+
+	if params.Query != "" {
+		result.Facets, result.FacetsApproximate, err = p.queryFacets(ctx, params, where, settings)
+	} else {
+		result.Facets, err = p.searchFacets(ctx, where)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// queryFacets counts facets over the top facetObjectLimit results of the
+// same hybrid query and filters the products come from, so counts agree
+// with the results a shopper pages through. When more results exist than
+// were counted, approximate is set.
+func (p *prodRepo) queryFacets(ctx context.Context, params models.SearchParams, where *filters.WhereBuilder, settings models.SearchSettings) (map[string][]models.FacetBucket, bool, error) {
+	fields := make([]weaviategraphql.Field, 0, len(facetProperties))
+	for _, prop := range facetProperties {
+		fields = append(fields, weaviategraphql.Field{Name: prop})
+	}
+
+	get := p.WDB.DB.GraphQL().Get().
+		WithClassName("Product").
+		WithFields(fields...).
+		WithWhere(where).
+		WithLimit(facetObjectLimit)
+
+	qctx, done := startQuery(ctx, "facet")
+	resp, err := p.withHybrid(get, params.Query, settings).Do(qctx)
+	done(err)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to count facets %v", err)
+	}
+	if len(resp.Errors) > 0 {
+		return nil, false, fmt.Errorf("failed to count facets %v", resp.Errors[0].Message)
+	}
+
+	rawProducts, err := classResults(resp.Data, "Get")
+	if err != nil {
+		return nil, false, err
+	}
+
+	facets := make(map[string][]models.FacetBucket, len(facetProperties))
+	for _, prop := range facetProperties {
+		counts := map[string]int{}
+		for _, raw := range rawProducts {
+			props, _ := raw.(map[string]any)
+			if value, _ := props[prop].(string); value != "" {
+				counts[value]++
+			}
+		}
+
+		buckets := make([]models.FacetBucket, 0, len(counts))
+		for value, count := range counts {
+			buckets = append(buckets, models.FacetBucket{Value: value, Count: count})
+		}
+		sort.Slice(buckets, func(i, j int) bool {
+			if buckets[i].Count != buckets[j].Count {
+				return buckets[i].Count > buckets[j].Count
+			}
+			return buckets[i].Value < buckets[j].Value
+		})
+		facets[prop] = buckets
+	}
+
+	return facets, len(rawProducts) == facetObjectLimit, nil
+}
+
+// searchFacets counts facets over every product matching where.
+func (p *prodRepo) searchFacets(ctx context.Context, where *filters.WhereBuilder) (map[string][]models.FacetBucket, error) {
+	facets := make(map[string][]models.FacetBucket, len(facetProperties))
+
+	for _, prop := range facetProperties {
+		agg := p.WDB.DB.GraphQL().Aggregate().
+			WithClassName("Product").
+			WithWhere(where).
+			WithGroupBy(prop).
+			WithFields(
+				weaviategraphql.Field{Name: "groupedBy", Fields: []weaviategraphql.Field{{Name: "value"}}},
+				weaviategraphql.Field{Name: "meta", Fields: []weaviategraphql.Field{{Name: "count"}}},
+			)
+
+		qctx, done := startQuery(ctx, "facet")
+		resp, err := agg.Do(qctx)
+		done(err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to aggregate %s facet %v", prop, err)
+		}
+		if len(resp.Errors) > 0 {
+			return nil, fmt.Errorf("failed to aggregate %s facet %v", prop, resp.Errors[0].Message)
+		}
+
+		groups, err := classResults(resp.Data, "Aggregate")
+		if err != nil {
+			return nil, err
+		}
+
+		buckets := make([]models.FacetBucket, 0, len(groups))
+		for _, g := range groups {
+			group, ok := g.(map[string]any)
+			if !ok {
+				continue
+			}
+			groupedBy, _ := group["groupedBy"].(map[string]any)
+			meta, _ := group["meta"].(map[string]any)
+			value, _ := groupedBy["value"].(string)
+			count, _ := meta["count"].(float64)
+			if value == "" {
+				continue
+			}
+			buckets = append(buckets, models.FacetBucket{Value: value, Count: int(count)})
+		}
+		facets[prop] = buckets
+	}
+
+	return facets, nil
+}
+
+// buildProductFilter scopes a query to an org and applies the structured
+// search filters.
+func buildProductFilter(params models.SearchParams) *filters.WhereBuilder {
+	return andFilter(productFilterOperands(params))
+}
+
+func productFilterOperands(params models.SearchParams) []*filters.WhereBuilder {
+	operands := []*filters.WhereBuilder{orgFilter(params.OrgID)}
+
+	if params.Brand != "" {
+		operands = append(operands, filters.Where().
+			WithPath([]string{"brand"}).
+			WithOperator(filters.Equal).
+			WithValueText(params.Brand))
+	}
+
+	for _, brand := range params.ExcludeBrands {
+		operands = append(operands, filters.Where().
+			WithPath([]string{"brand"}).
+			WithOperator(filters.NotEqual).
+			WithValueText(brand))
+	}
+
+	// Products without availability data are kept, matching
+	// models.RankByAvailability.
+	if params.InStockOnly {
+		operands = append(operands, filters.Where().
+			WithPath([]string{"availability"}).
+			WithOperator(filters.NotEqual).
+			WithValueText(models.AvailabilityOutOfStock))
+	}
+
+	if params.MinPrice != nil {
+		operands = append(operands, filters.Where().
+			WithPath([]string{"minPrice"}).
+			WithOperator(filters.GreaterThanEqual).
+			WithValueNumber(*params.MinPrice))
+	}
+
+	if params.MaxPrice != nil {
+		operands = append(operands, filters.Where().
+			WithPath([]string{"minPrice"}).
+			WithOperator(filters.LessThanEqual).
+			WithValueNumber(*params.MaxPrice))
+	}
+
+	return operands
+}
+
+// orgFilter and productFilter match whole IDs through the field-tokenized
+// orgKey and productKey. Filtering on orgId or productId would also match
+// IDs sharing a word, such as prod-123 and prod-123-b.
+func orgFilter(orgID string) *filters.WhereBuilder {
+	return filters.Where().
+		WithPath([]string{"orgKey"}).
+		WithOperator(filters.Equal).
+		WithValueText(orgID)
+}
+
+func productFilter(operator filters.WhereOperator, productID string) *filters.WhereBuilder {
+	return filters.Where().
+		WithPath([]string{"productKey"}).
+		WithOperator(operator).
+		WithValueText(productID)
+}
+
+func andFilter(operands []*filters.WhereBuilder) *filters.WhereBuilder {
+	if len(operands) == 1 {
+		return operands[0]
+	}
+
+	return filters.Where().WithOperator(filters.And).WithOperands(operands)
+}
+
+// classResults pulls the Product list out of a Get or Aggregate response.
+func classResults(data map[string]weaviatemodels.JSONObject, root string) ([]any, error) {
+	rootMap, ok := data[root].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s response format", root)
+	}
+
+	results, ok := rootMap["Product"].([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid Product result format")
+	}
+
+	return results, nil
+}
+
+func parseScore(v any) float64 {
+	switch s := v.(type) {
+	case string:
+		f, _ := strconv.ParseFloat(s, 64)
+		return f
+	case float64:
+		return s
+	}
+	return 0
+}
+
+// EncodeCursor turns a result offset into an opaque page cursor.
+func EncodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+// DecodeCursor reverses EncodeCursor. An empty cursor is the first page.
+func DecodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return offset, nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+)
+
+func TestQueryFacetsCountTheQueryResults(t *testing.T) {
+	repo := newTestRepo(t, newFakeWeaviate(
+		product("00000000-0000-0000-0000-000000000001", "acme", "p1", map[string]any{"brand": "nike", "attr_1_value": "red"}),
+		product("00000000-0000-0000-0000-000000000002", "acme", "p2", map[string]any{"brand": "puma", "attr_1_value": "red"}),
+		product("00000000-0000-0000-0000-000000000003", "acme", "p3", map[string]any{"brand": "nike", "attr_1_value": "blue"}),
+		// Filtered out, so not counted.
+		product("00000000-0000-0000-0000-000000000004", "acme", "p4", map[string]any{"brand": "adidas", "availability": models.AvailabilityOutOfStock}),
+		product("00000000-0000-0000-0000-000000000005", "acme-eu", "p5", map[string]any{"brand": "nike"}),
+	))
+
+	params := models.SearchParams{OrgID: "acme", Query: "shoes", InStockOnly: true}
+	facets, approximate, err := repo.queryFacets(context.Background(), params, buildProductFilter(params), models.SearchSettings{Alpha: 0.5})
+	if err != nil {
+		t.Fatalf("queryFacets: %v", err)
+	}
+
+	if approximate {
+		t.Error("facets of a small result set are marked approximate")
+	}
+	wantBrands := []models.FacetBucket{{Value: "nike", Count: 2}, {Value: "puma", Count: 1}}
+	if !slices.Equal(facets["brand"], wantBrands) {
+		t.Errorf("brand facets = %v, want %v", facets["brand"], wantBrands)
+	}
+	wantValues := []models.FacetBucket{{Value: "red", Count: 2}, {Value: "blue", Count: 1}}
+	if !slices.Equal(facets["attr_1_value"], wantValues) {
+		t.Errorf("attr_1_value facets = %v, want %v", facets["attr_1_value"], wantValues)
+	}
+}
+
+func TestQueryFacetsApproximateWhenCapped(t *testing.T) {
+	objects := make([]fakeObject, 0, facetObjectLimit+1)
+	for i := range facetObjectLimit + 1 {
+		objects = append(objects, product(fmt.Sprintf("00000000-0000-0000-0000-%012d", i), "acme", fmt.Sprintf("p%d", i), map[string]any{"brand": "nike"}))
+	}
+	repo := newTestRepo(t, newFakeWeaviate(objects...))
+
+	params := models.SearchParams{OrgID: "acme", Query: "shoes"}
+	facets, approximate, err := repo.queryFacets(context.Background(), params, buildProductFilter(params), models.SearchSettings{Alpha: 0.5})
+	if err != nil {
+		t.Fatalf("queryFacets: %v", err)
+	}
+
+	if !approximate {
+		t.Error("capped facets are not marked approximate")
+	}
+	if want := []models.FacetBucket{{Value: "nike", Count: facetObjectLimit}}; !slices.Equal(facets["brand"], want) {
+		t.Errorf("brand facets = %v, want %v", facets["brand"], want)
+	}
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/metrics"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/filters"
+	weaviategraphql "github.com/weaviate/weaviate-go-client/v4/weaviate/graphql"
+)
+
+var ErrProductNotFound = fmt.Errorf("product not found")
+
+func (p *prodRepo) GetProduct(ctx context.Context, orgID string, productID string) (*models.ProductHit, error) {
+	hits, err := p.findProducts(ctx, orgID, productID, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 {
+		return nil, ErrProductNotFound
+	}
+
+	return &hits[0], nil
+}
+
+// productCopies returns every stored object of a product.
+func (p *prodRepo) productCopies(ctx context.Context, orgID string, productID string) ([]models.ProductHit, error) {
+	return p.findProducts(ctx, orgID, productID, maxProductCopies)
+}
+
+func (p *prodRepo) findProducts(ctx context.Context, orgID string, productID string, limit int) ([]models.ProductHit, error) {
+	where := andFilter([]*filters.WhereBuilder{
+		orgFilter(orgID),
+		productFilter(filters.Equal, productID),
+	})
+
+	fields := append([]weaviategraphql.Field{
+		{Name: "_additional", Fields: []weaviategraphql.Field{{Name: "id"}}},
+	}, productFields...)
+
+	qctx, done := startQuery(ctx, "get_product")
+	resp, err := p.WDB.DB.GraphQL().Get().
+		WithClassName("Product").
+		WithFields(fields...).
+		WithWhere(where).
+		WithLimit(limit).
+		Do(qctx)
+	done(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product %v", err)
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("failed to get product %v", resp.Errors[0].Message)
+	}
+
+	rawProducts, err := classResults(resp.Data, "Get")
+	if err != nil {
+		return nil, err
+	}
+
+	return toProductHits(rawProducts), nil
+}
+
+// SimilarProducts runs a nearObject query anchored on the stored vector of
+// params.ProductID. The source product and every object sharing its
+// productId (variants uploaded separately) are excluded.
+func (p *prodRepo) SimilarProducts(ctx context.Context, params models.SimilarParams) ([]models.ProductHit, error) {
+	source, err := p.GetProduct(ctx, params.OrgID, params.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Limit <= 0 {
+		params.Limit = p.searchSettings(ctx, params.OrgID).Limit
+	}
+
+	operands := append(productFilterOperands(params.SearchParams), productFilter(filters.NotEqual, params.ProductID))
+
+	fields := append([]weaviategraphql.Field{
+		{Name: "_additional", Fields: []weaviategraphql.Field{{Name: "id"}, {Name: "distance"}}},
+	}, productFields...)
+
+	qctx, done := startQuery(ctx, "similar")
+	resp, err := p.WDB.DB.GraphQL().Get().
+		WithClassName("Product").
+		WithFields(fields...).
+		WithWhere(andFilter(operands)).
+		WithNearObject(p.WDB.DB.GraphQL().NearObjectArgBuilder().WithID(source.ID)).
+		WithLimit(params.Limit).
+		WithOffset(params.Offset).
+		Do(qctx)
+	done(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get similar products %v", err)
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("failed to get similar products %v", resp.Errors[0].Message)
+	}
+
+	rawProducts, err := classResults(resp.Data, "Get")
+	if err != nil {
+		return nil, err
+	}
+	metrics.ObserveResults("similar", len(rawProducts))
+
+	return models.RankByAvailability(toProductHits(rawProducts), params.InStockOnly), nil
+}
+
+// toProductHits strips _additional from raw Get results into ProductHits.
+// A nearObject distance is reported as a 1 - distance score so higher is
+// always better.
+func toProductHits(rawProducts []any) []models.ProductHit {
+	hits := make([]models.ProductHit, 0, len(rawProducts))
+
+	for _, raw := range rawProducts {
+		props, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		hit := models.ProductHit{Properties: props}
+		if additional, ok := props["_additional"].(map[string]any); ok {
+			hit.ID, _ = additional["id"].(string)
+			if _, ok := additional["distance"]; ok {
+				hit.Score = 1 - parseScore(additional["distance"])
+			} else {
+				hit.Score = parseScore(additional["score"])
+			}
+			delete(props, "_additional")
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits
+}
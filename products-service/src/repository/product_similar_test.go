@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+)
+
+// overlappingCatalog holds IDs that share words with prod-123 in org acme.
+// Word tokenization matches all of them.
+func overlappingCatalog() *fakeWeaviate {
+	return newFakeWeaviate(
+		product("00000000-0000-0000-0000-000000000001", "acme", "prod-123-b", nil),
+		product("00000000-0000-0000-0000-000000000002", "acme-eu", "prod-123", nil),
+		product("00000000-0000-0000-0000-000000000003", "acme", "prod-123", nil),
+		product("00000000-0000-0000-0000-000000000004", "acme", "123", nil),
+	)
+}
+
+func hitIDs(hits []models.ProductHit) []string {
+	out := make([]string, 0, len(hits))
+	for _, h := range hits {
+		out = append(out, h.ID)
+	}
+	return out
+}
+
+func TestGetProductMatchesWholeIDs(t *testing.T) {
+	repo := newTestRepo(t, overlappingCatalog())
+
+	hit, err := repo.GetProduct(context.Background(), "acme", "prod-123")
+	if err != nil {
+		t.Fatalf("GetProduct: %v", err)
+	}
+	if hit.ID != "00000000-0000-0000-0000-000000000003" {
+		t.Errorf("got object %s (%v/%v), want acme/prod-123", hit.ID, hit.Properties["orgId"], hit.Properties["productId"])
+	}
+
+	if _, err := repo.GetProduct(context.Background(), "acme", "prod"); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("GetProduct of a partial ID: err = %v, want ErrProductNotFound", err)
+	}
+}
+
+func TestSimilarProductsExcludesOnlyTheSource(t *testing.T) {
+	repo := newTestRepo(t, overlappingCatalog())
+
+	hits, err := repo.SimilarProducts(context.Background(), models.SimilarParams{
+		SearchParams: models.SearchParams{OrgID: "acme", Limit: 10},
+		ProductID:    "prod-123",
+	})
+	if err != nil {
+		t.Fatalf("SimilarProducts: %v", err)
+	}
+
+	want := []string{"00000000-0000-0000-0000-000000000001", "00000000-0000-0000-0000-000000000004"}
+	if got := hitIDs(hits); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
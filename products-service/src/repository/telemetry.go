@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Adityadangi14/ecomm_ai/pkg/tracing"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/metrics"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startQuery starts a client span for one Weaviate request. done records
+// the request's latency and ends the span.
+func startQuery(ctx context.Context, operation string) (context.Context, func(err error)) {
+	ctx, span := tracing.Tracer().Start(ctx, "weaviate "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameKey.String("weaviate"),
+			semconv.DBCollectionName("Product"),
+			semconv.DBOperationName(operation),
+		),
+	)
+
+	start := time.Now()
+	return ctx, func(err error) {
+		metrics.ObserveWeaviate(operation, start, err)
+		tracing.Fail(span, err)
+		span.End()
+	}
+}
@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"unicode"
+
+	"github.com/Adityadangi14/ecomm_ai/pkg/WDB"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate"
+)
+
+// fakeWeaviate serves the parts of the Weaviate REST and GraphQL API the
+// repository uses, over an in-memory Product class. Text filters follow
+// Weaviate's tokenization: field-tokenized properties match whole values,
+// the rest match when every word of the filter value is present.
+type fakeWeaviate struct {
+	mu      sync.Mutex
+	objects []fakeObject
+}
+
+type fakeObject struct {
+	id    string
+	props map[string]any
+}
+
+// fieldTokenized are the Product properties created with field tokenization.
+var fieldTokenized = map[string]bool{"orgKey": true, "productKey": true, "availability": true}
+
+type textFilter struct {
+	path     string
+	operator string
+	value    string
+}
+
+var (
+	graphqlFilterRe = regexp.MustCompile(`operator: (\w+) path: \["(\w+)"\] valueText: ("(?:[^"\\]|\\.)*")`)
+	graphqlLimitRe  = regexp.MustCompile(`limit: (\d+)`)
+)
+
+func newFakeWeaviate(objects ...fakeObject) *fakeWeaviate {
+	return &fakeWeaviate{objects: objects}
+}
+
+// product is a stored product as SaveProduct writes it.
+func product(id, orgID, productID string, extra map[string]any) fakeObject {
+	props := map[string]any{"orgId": orgID, "productId": productID, "orgKey": orgID, "productKey": productID}
+	for k, v := range extra {
+		props[k] = v
+	}
+	return fakeObject{id: id, props: props}
+}
+
+// newTestRepo returns a repository backed by fake.
+func newTestRepo(t *testing.T, fake *fakeWeaviate) *prodRepo {
+	t.Helper()
+
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	u, _ := url.Parse(srv.URL)
+	client, err := weaviate.NewClient(weaviate.Config{Scheme: u.Scheme, Host: u.Host})
+	if err != nil {
+		t.Fatalf("weaviate.NewClient: %v", err)
+	}
+
+	return &prodRepo{WDB: &WDB.WDB{DB: client}, log: slog.New(slog.NewTextHandler(io.Discard, nil)), lock: localLock()}
+}
+
+// localLock serializes product writes within the test process.
+func localLock() func(ctx context.Context, orgID string, productID string) (func(), error) {
+	var mu sync.Mutex
+	locks := map[string]*sync.Mutex{}
+	return func(ctx context.Context, orgID string, productID string) (func(), error) {
+		mu.Lock()
+		l, ok := locks[orgID+"/"+productID]
+		if !ok {
+			l = &sync.Mutex{}
+			locks[orgID+"/"+productID] = l
+		}
+		mu.Unlock()
+
+		l.Lock()
+		return l.Unlock, nil
+	}
+}
+
+func (f *fakeWeaviate) object(id string) (fakeObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, o := range f.objects {
+		if o.id == id {
+			return o, true
+		}
+	}
+	return fakeObject{}, false
+}
+
+func (f *fakeWeaviate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/v1/meta":
+		writeJSON(w, map[string]any{"version": "1.27.0"})
+	case r.URL.Path == "/v1/graphql" && r.Method == http.MethodPost:
+		f.graphql(w, r)
+	case r.URL.Path == "/v1/batch/objects" && r.Method == http.MethodPost:
+		f.batch(w, r)
+	case strings.HasPrefix(r.URL.Path, "/v1/objects/Product/") && r.Method == http.MethodPatch:
+		f.patch(w, r, strings.TrimPrefix(r.URL.Path, "/v1/objects/Product/"))
+	case strings.HasPrefix(r.URL.Path, "/v1/objects/Product/") && r.Method == http.MethodDelete:
+		f.delete(w, r, strings.TrimPrefix(r.URL.Path, "/v1/objects/Product/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeWeaviate) graphql(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Query string `json:"query"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var where []textFilter
+	for _, m := range graphqlFilterRe.FindAllStringSubmatch(body.Query, -1) {
+		value, _ := strconv.Unquote(m[3])
+		where = append(where, textFilter{path: m[2], operator: m[1], value: value})
+	}
+	limit := len(f.objects)
+	if m := graphqlLimitRe.FindStringSubmatch(body.Query); m != nil {
+		limit, _ = strconv.Atoi(m[1])
+	}
+
+	results := []any{}
+	for _, o := range f.objects {
+		if len(results) == limit {
+			break
+		}
+		if !matchesAll(o, where) {
+			continue
+		}
+		props := map[string]any{"_additional": map[string]any{"id": o.id}}
+		for k, v := range o.props {
+			props[k] = v
+		}
+		results = append(results, props)
+	}
+
+	writeJSON(w, map[string]any{"data": map[string]any{"Get": map[string]any{"Product": results}}})
+}
+
+func (f *fakeWeaviate) patch(w http.ResponseWriter, r *http.Request, id string) {
+	var body struct {
+		Properties map[string]any `json:"properties"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, o := range f.objects {
+		if o.id == id {
+			for k, v := range body.Properties {
+				o.props[k] = v
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// batch imports objects, replacing those with the same ID.
+func (f *fakeWeaviate) batch(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Objects []struct {
+			ID         string         `json:"id"`
+			Properties map[string]any `json:"properties"`
+		} `json:"objects"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results := []any{}
+	for _, in := range body.Objects {
+		replaced := false
+		for i, o := range f.objects {
+			if o.id == in.ID {
+				f.objects[i].props = in.Properties
+				replaced = true
+			}
+		}
+		if !replaced {
+			f.objects = append(f.objects, fakeObject{id: in.ID, props: in.Properties})
+		}
+		results = append(results, map[string]any{"class": "Product", "id": in.ID, "result": map[string]any{}})
+	}
+	writeJSON(w, results)
+}
+
+func (f *fakeWeaviate) delete(w http.ResponseWriter, r *http.Request, id string) {
+	for i, o := range f.objects {
+		if o.id == id {
+			f.objects = append(f.objects[:i], f.objects[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func matchesAll(o fakeObject, where []textFilter) bool {
+	for _, flt := range where {
+		value, _ := o.props[flt.path].(string)
+
+		equal := value == flt.value
+		if !fieldTokenized[flt.path] {
+			equal = containsWords(words(value), words(flt.value))
+		}
+
+		switch flt.operator {
+		case "Equal":
+			if !equal {
+				return false
+			}
+		case "NotEqual":
+			if equal {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// words splits s like Weaviate's word tokenization.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func containsWords(have, want []string) bool {
+	if len(want) == 0 {
+		return false
+	}
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if h == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
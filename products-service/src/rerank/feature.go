@@ -0,0 +1,110 @@
+package rerank
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+)
+
+const (
+	weightBase  = 0.5
+	weightPrice = 0.2
+	weightBrand = 0.2
+	weightStock = 0.1
+)
+
+var budgetRe = regexp.MustCompile(`(?i)(?:under|below|less than|within|up to|upto|max|<)\s*(?:rs\.?|inr|usd|\$|₹)?\s*(\d+(?:\.\d+)?)\s*(k)?`)
+
+type featureReranker struct{}
+
+// NewFeatureReranker scores candidates on the retrieval score plus price fit
+// against a budget in the query, brand mentions and stock.
+func NewFeatureReranker() Reranker {
+	return &featureReranker{}
+}
+
+func (f *featureReranker) Rerank(ctx context.Context, query string, candidates []models.ProductHit, topK int) ([]models.ProductHit, error) {
+	budget, hasBudget := parseBudget(query)
+	lowerQuery := strings.ToLower(query)
+
+	maxBase := 0.0
+	for _, c := range candidates {
+		if c.Score > maxBase {
+			maxBase = c.Score
+		}
+	}
+
+	hits := make([]models.ProductHit, len(candidates))
+	for i, c := range candidates {
+		base := 0.0
+		if maxBase > 0 {
+			base = c.Score / maxBase
+		}
+
+		price := priceFit(c.Properties, budget, hasBudget)
+		brand := brandMatch(c.Properties, lowerQuery)
+		stock := stockScore(c.Properties)
+
+		c.ScoreDebug = map[string]float64{
+			"base":  base,
+			"price": price,
+			"brand": brand,
+			"stock": stock,
+		}
+		c.Score = weightBase*base + weightPrice*price + weightBrand*brand + weightStock*stock
+		hits[i] = c
+	}
+
+	return sortAndCut(hits, topK), nil
+}
+
+func parseBudget(query string) (float64, bool) {
+	m := budgetRe.FindStringSubmatch(query)
+	if m == nil {
+		return 0, false
+	}
+	budget, err := strconv.ParseFloat(m[1], 64)
+	if err != nil || budget <= 0 {
+		return 0, false
+	}
+	if m[2] != "" {
+		budget *= 1000
+	}
+	return budget, true
+}
+
+// priceFit is 1 within budget and falls off linearly above it. Without a
+// budget or a known price every product is neutral.
+func priceFit(props map[string]any, budget float64, hasBudget bool) float64 {
+	price, ok := props["minPrice"].(float64)
+	if !hasBudget || !ok {
+		return 0.5
+	}
+	if price <= budget {
+		return 1
+	}
+	fit := 1 - (price-budget)/budget
+	if fit < 0 {
+		return 0
+	}
+	return fit
+}
+
+func brandMatch(props map[string]any, lowerQuery string) float64 {
+	brand, _ := props["brand"].(string)
+	if brand != "" && strings.Contains(lowerQuery, strings.ToLower(brand)) {
+		return 1
+	}
+	return 0
+}
+
+// stockScore treats products without availability data as in stock.
+func stockScore(props map[string]any) float64 {
+	if availability, _ := props["availability"].(string); availability == models.AvailabilityOutOfStock {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,154 @@
+package rerank
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+)
+
+func candidate(id string, score float64, props map[string]any) models.ProductHit {
+	p := map[string]any{"productId": id, "name": id}
+	for k, v := range props {
+		p[k] = v
+	}
+	return models.ProductHit{Score: score, Properties: p}
+}
+
+func hitIDs(hits []models.ProductHit) []string {
+	out := make([]string, 0, len(hits))
+	for _, h := range hits {
+		id, _ := h.Properties["productId"].(string)
+		out = append(out, id)
+	}
+	return out
+}
+
+func TestFeatureRerank(t *testing.T) {
+	acme := candidate("a", 0.8, map[string]any{"minPrice": 120.0, "brand": "Acme", "availability": models.AvailabilityInStock})
+	globex := candidate("b", 1.0, map[string]any{"minPrice": 300.0, "brand": "Globex", "availability": models.AvailabilityInStock})
+	initech := candidate("c", 0.9, map[string]any{"minPrice": 90.0, "brand": "Initech", "availability": models.AvailabilityOutOfStock})
+
+	tests := []struct {
+		name       string
+		query      string
+		candidates []models.ProductHit
+		topK       int
+		want       []string
+	}{
+		{
+			name:       "fuses retrieval, price, brand and stock",
+			query:      "acme shoes under 150",
+			candidates: []models.ProductHit{acme, globex, initech},
+			want:       []string{"a", "c", "b"},
+		},
+		{
+			name:       "without a budget or brand retrieval and stock decide",
+			query:      "shoes",
+			candidates: []models.ProductHit{acme, globex, initech},
+			want:       []string{"b", "a", "c"},
+		},
+		{
+			name:       "cuts to topK",
+			query:      "acme shoes under 150",
+			candidates: []models.ProductHit{acme, globex, initech},
+			topK:       2,
+			want:       []string{"a", "c"},
+		},
+		{
+			name:  "ties keep retrieval order",
+			query: "shoes",
+			candidates: []models.ProductHit{
+				candidate("x", 0.5, nil),
+				candidate("y", 0.5, nil),
+				candidate("z", 0.5, nil),
+			},
+			want: []string{"x", "y", "z"},
+		},
+		{
+			name:       "empty input",
+			query:      "shoes",
+			candidates: []models.ProductHit{},
+			topK:       5,
+			want:       []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewFeatureReranker().Rerank(context.Background(), tt.query, tt.candidates, tt.topK)
+			if err != nil {
+				t.Fatalf("Rerank: %v", err)
+			}
+			if ids := hitIDs(got); !slices.Equal(ids, tt.want) {
+				t.Errorf("order = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeatureRerankScores(t *testing.T) {
+	candidates := []models.ProductHit{
+		candidate("a", 0.8, map[string]any{"minPrice": 120.0, "brand": "Acme"}),
+		candidate("b", 1.0, map[string]any{"minPrice": 225.0, "brand": "Globex", "availability": models.AvailabilityOutOfStock}),
+	}
+
+	got, err := NewFeatureReranker().Rerank(context.Background(), "Acme boots under 150", candidates, 0)
+	if err != nil {
+		t.Fatalf("Rerank: %v", err)
+	}
+
+	want := map[string]struct {
+		score float64
+		debug map[string]float64
+	}{
+		// 0.5*0.8 + 0.2*1 + 0.2*1 + 0.1*1
+		"a": {0.9, map[string]float64{"base": 0.8, "price": 1, "brand": 1, "stock": 1}},
+		// 0.5*1 + 0.2*0.5 + 0.2*0 + 0.1*0
+		"b": {0.6, map[string]float64{"base": 1, "price": 0.5, "brand": 0, "stock": 0}},
+	}
+	for _, h := range got {
+		id := h.Properties["productId"].(string)
+		w := want[id]
+		if !almostEqual(h.Score, w.score) {
+			t.Errorf("%s score = %v, want %v", id, h.Score, w.score)
+		}
+		for k, v := range w.debug {
+			if !almostEqual(h.ScoreDebug[k], v) {
+				t.Errorf("%s %s = %v, want %v", id, k, h.ScoreDebug[k], v)
+			}
+		}
+	}
+
+	if candidates[0].ScoreDebug != nil || candidates[0].Score != 0.8 {
+		t.Errorf("Rerank modified its input: %+v", candidates[0])
+	}
+}
+
+func TestParseBudget(t *testing.T) {
+	tests := []struct {
+		query string
+		want  float64
+		ok    bool
+	}{
+		{query: "shoes under 150", want: 150, ok: true},
+		{query: "phones below ₹20k", want: 20000, ok: true},
+		{query: "a bag up to $49.99", want: 49.99, ok: true},
+		{query: "MAX 300 for a jacket", want: 300, ok: true},
+		{query: "size 42 shoes", ok: false},
+		{query: "under 0", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseBudget(tt.query)
+		if ok != tt.ok || !almostEqual(got, tt.want) {
+			t.Errorf("parseBudget(%q) = %v, %v, want %v, %v", tt.query, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
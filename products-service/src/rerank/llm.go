@@ -0,0 +1,73 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+)
+
+const maxDescriptionChars = 200
+
+type llmReranker struct {
+	ranker ListwiseRanker
+}
+
+// NewLLMReranker asks the LLM provider to order all candidates in one call.
+func NewLLMReranker(ranker ListwiseRanker) Reranker {
+	return &llmReranker{ranker: ranker}
+}
+
+func (l *llmReranker) Rerank(ctx context.Context, query string, candidates []models.ProductHit, topK int) ([]models.ProductHit, error) {
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	docs := make([]string, len(candidates))
+	for i, c := range candidates {
+		docs[i] = describe(c.Properties)
+	}
+
+	order, err := l.ranker.RankProducts(ctx, query, docs)
+	if err != nil {
+		return nil, fmt.Errorf("listwise rerank failed: %w", err)
+	}
+
+	// Candidates the model dropped keep their retrieval order after the
+	// ranked ones.
+	seen := make(map[int]bool, len(candidates))
+	ranked := make([]int, 0, len(candidates))
+	for _, idx := range order {
+		if idx >= 0 && idx < len(candidates) && !seen[idx] {
+			seen[idx] = true
+			ranked = append(ranked, idx)
+		}
+	}
+	for i := range candidates {
+		if !seen[i] {
+			ranked = append(ranked, i)
+		}
+	}
+
+	hits := make([]models.ProductHit, len(ranked))
+	for pos, idx := range ranked {
+		c := candidates[idx]
+		c.ScoreDebug = map[string]float64{
+			"base":    c.Score,
+			"llmRank": float64(pos + 1),
+		}
+		c.Score = 1 - float64(pos)/float64(len(ranked))
+		hits[pos] = c
+	}
+
+	return sortAndCut(hits, topK), nil
+}
+
+func describe(props map[string]any) string {
+	description, _ := props["description"].(string)
+	if runes := []rune(description); len(runes) > maxDescriptionChars {
+		description = string(runes[:maxDescriptionChars])
+	}
+	return fmt.Sprintf("%v | %v | %v %v | %v",
+		props["name"], props["brand"], props["minPrice"], props["priceCurrency"], description)
+}
@@ -0,0 +1,119 @@
+package rerank
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+)
+
+type fakeRanker struct {
+	order []int
+	err   error
+	calls int
+}
+
+func (f *fakeRanker) RankProducts(ctx context.Context, query string, products []string) ([]int, error) {
+	f.calls++
+	return f.order, f.err
+}
+
+func TestLLMRerank(t *testing.T) {
+	candidates := func() []models.ProductHit {
+		return []models.ProductHit{
+			candidate("a", 0.9, nil),
+			candidate("b", 0.8, nil),
+			candidate("c", 0.7, nil),
+			candidate("d", 0.6, nil),
+		}
+	}
+
+	tests := []struct {
+		name  string
+		order []int
+		topK  int
+		want  []string
+	}{
+		{name: "follows the model's order", order: []int{2, 0, 3, 1}, want: []string{"c", "a", "d", "b"}},
+		{name: "dropped candidates follow in retrieval order", order: []int{3}, want: []string{"d", "a", "b", "c"}},
+		{name: "ignores out of range and repeated indices", order: []int{1, 7, -1, 1, 0}, want: []string{"b", "a", "c", "d"}},
+		{name: "empty answer keeps retrieval order", order: nil, want: []string{"a", "b", "c", "d"}},
+		{name: "cuts to topK", order: []int{3, 2, 1, 0}, topK: 2, want: []string{"d", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewLLMReranker(&fakeRanker{order: tt.order}).Rerank(context.Background(), "shoes", candidates(), tt.topK)
+			if err != nil {
+				t.Fatalf("Rerank: %v", err)
+			}
+			if ids := hitIDs(got); !slices.Equal(ids, tt.want) {
+				t.Errorf("order = %v, want %v", ids, tt.want)
+			}
+			for i := 1; i < len(got); i++ {
+				if got[i].Score >= got[i-1].Score {
+					t.Errorf("scores not strictly decreasing at %d: %v then %v", i, got[i-1].Score, got[i].Score)
+				}
+			}
+		})
+	}
+}
+
+func TestLLMRerankDebugScores(t *testing.T) {
+	got, err := NewLLMReranker(&fakeRanker{order: []int{1, 0}}).Rerank(context.Background(), "shoes",
+		[]models.ProductHit{candidate("a", 0.9, nil), candidate("b", 0.4, nil)}, 0)
+	if err != nil {
+		t.Fatalf("Rerank: %v", err)
+	}
+
+	first := got[0]
+	if first.Score != 1 || first.ScoreDebug["llmRank"] != 1 || first.ScoreDebug["base"] != 0.4 {
+		t.Errorf("first hit = score %v debug %v, want score 1, llmRank 1, base 0.4", first.Score, first.ScoreDebug)
+	}
+	second := got[1]
+	if second.Score != 0.5 || second.ScoreDebug["llmRank"] != 2 || second.ScoreDebug["base"] != 0.9 {
+		t.Errorf("second hit = score %v debug %v, want score 0.5, llmRank 2, base 0.9", second.Score, second.ScoreDebug)
+	}
+}
+
+func TestLLMRerankFailure(t *testing.T) {
+	cause := errors.New("model unavailable")
+	ranker := &fakeRanker{err: cause}
+
+	got, err := NewLLMReranker(ranker).Rerank(context.Background(), "shoes", []models.ProductHit{candidate("a", 0.9, nil)}, 5)
+	if !errors.Is(err, cause) {
+		t.Fatalf("err = %v, want %v", err, cause)
+	}
+	if got != nil {
+		t.Errorf("hits = %v, want none on failure", hitIDs(got))
+	}
+}
+
+func TestLLMRerankEmpty(t *testing.T) {
+	ranker := &fakeRanker{}
+
+	got, err := NewLLMReranker(ranker).Rerank(context.Background(), "shoes", []models.ProductHit{}, 5)
+	if err != nil {
+		t.Fatalf("Rerank: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("hits = %v, want none", hitIDs(got))
+	}
+	if ranker.calls != 0 {
+		t.Errorf("ranker called %d times for no candidates", ranker.calls)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if New(models.RerankerNone, nil) != nil {
+		t.Error("New(none) should disable reranking")
+	}
+	if _, ok := New(models.RerankerLLM, &fakeRanker{}).(*llmReranker); !ok {
+		t.Error("New(llm) should return the LLM reranker")
+	}
+	if _, ok := New(models.RerankerFeature, nil).(*featureReranker); !ok {
+		t.Error("New(feature) should return the feature reranker")
+	}
+}
@@ -0,0 +1,63 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+)
+
+// Reranker reorders retrieved candidates and returns at most topK of them.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, candidates []models.ProductHit, topK int) ([]models.ProductHit, error)
+}
+
+// ListwiseRanker is implemented by the LLM provider. It receives one short
+// description per product and returns their indices, most relevant first.
+type ListwiseRanker interface {
+	RankProducts(ctx context.Context, query string, products []string) ([]int, error)
+}
+
+// New returns the reranker selected in an org's search settings, or nil when
+// reranking is disabled.
+func New(name string, ranker ListwiseRanker) Reranker {
+	switch name {
+	case models.RerankerLLM:
+		return NewLLMReranker(ranker)
+	case models.RerankerFeature:
+		return NewFeatureReranker()
+	}
+	return nil
+}
+
+func sortAndCut(hits []models.ProductHit, topK int) []models.ProductHit {
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	if topK > 0 && len(hits) > topK {
+		hits = hits[:topK]
+	}
+	return hits
+}
+
+// DebugTable renders the per-feature scores of reranked hits for logging.
+func DebugTable(hits []models.ProductHit) string {
+	var builder strings.Builder
+	for i, h := range hits {
+		name, _ := h.Properties["name"].(string)
+		builder.WriteString(fmt.Sprintf("%2d. %.3f %s", i+1, h.Score, name))
+
+		keys := make([]string, 0, len(h.ScoreDebug))
+		for k := range h.ScoreDebug {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			builder.WriteString(fmt.Sprintf(" %s=%.3f", k, h.ScoreDebug[k]))
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
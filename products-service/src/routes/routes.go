@@ -2,13 +2,62 @@ package routes
 
 import (
 	"github.com/Adityadangi14/ecomm_ai/products-service/handlers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/auth"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/models"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/quota"
 	"github.com/gofiber/fiber/v2"
 )
 
-func RegisterRoutes(app *fiber.App, handlers handlers.Handlers) {
+func RegisterRoutes(app *fiber.App, handlers handlers.Handlers, limiter quota.Limiter, authn auth.Authenticator) {
+	app.Get("/healthz", handlers.HealthHandler.Liveness)
+	app.Get("/readyz", handlers.HealthHandler.Readiness)
+
 	v1 := app.Group("api/v1")
 
-	v1.Post("/uploadProducts", handlers.ProductHandlers.UploadProducts)
-	v1.Delete("/deleteAllProducts", handlers.ProductHandlers.DeleteAllProducts)
-	v1.Post("/response", handlers.QueryHandler.GetAiResponse)
+	can := authn.RequirePermission
+
+	v1.Post("/auth/anonymous", can(models.PermShopperTokens), handlers.AuthHandler.IssueAnonymousToken)
+	v1.Post("/uploadProducts", can(models.PermCatalogWrite), limiter.UploadLimit(), handlers.ProductHandlers.UploadProducts)
+	v1.Delete("/deleteAllProducts", can(models.PermPlatformManage), handlers.ProductHandlers.DeleteAllProducts)
+	v1.Post("/response", can(models.PermChat), limiter.ChatLimit(), handlers.QueryHandler.GetAiResponse)
+	v1.Get("/orgs/:orgId/search", can(models.PermChat), handlers.SearchHandler.SearchProducts)
+	v1.Get("/orgs/:orgId/products/:productId/similar", can(models.PermChat), handlers.SearchHandler.SimilarProducts)
+	v1.Patch("/orgs/:orgId/products/:productId/stock", can(models.PermCatalogWrite), handlers.ProductHandlers.UpdateStock)
+	v1.Post("/orgs/:orgId/products/updates", can(models.PermCatalogWrite), handlers.ProductHandlers.PublishUpdates)
+	v1.Get("/sessions/:sessionId/messages", can(models.PermShopperData), handlers.ConvHandler.GetSessionMessages)
+	v1.Get("/orgs/:orgId/users/:userId/sessions", can(models.PermShopperData), handlers.ConvHandler.ListUserSessions)
+	v1.Delete("/sessions/:sessionId", can(models.PermShopperData), handlers.PrivacyHandler.DeleteSession)
+	v1.Delete("/orgs/:orgId/users/:userId/data", can(models.PermUsersErase), handlers.PrivacyHandler.EraseUserData)
+	v1.Get("/orgs/:orgId/users/:userId/preferences", can(models.PermShopperData), handlers.PrefsHandler.GetPreferences)
+	v1.Put("/orgs/:orgId/users/:userId/preferences", can(models.PermShopperData), handlers.PrefsHandler.UpdatePreferences)
+	v1.Delete("/orgs/:orgId/users/:userId/preferences", can(models.PermShopperData), handlers.PrefsHandler.DeletePreferences)
+	v1.Get("/orgs/:orgId/usage", can(models.PermAnalytics), handlers.UsageHandler.GetUsage)
+
+	admin := v1.Group("/admin/orgs/:orgId")
+	admin.Get("/search/settings", can(models.PermSettingsRead), handlers.SettingsHandler.GetSearchSettings)
+	admin.Put("/search/settings", can(models.PermSettingsWrite), handlers.SettingsHandler.UpdateSearchSettings)
+	admin.Delete("/search/settings", can(models.PermSettingsWrite), handlers.SettingsHandler.ResetSearchSettings)
+
+	admin.Get("/merchandising/rules", can(models.PermSettingsRead), handlers.MerchHandler.ListRules)
+	admin.Post("/merchandising/rules", can(models.PermMerchWrite), handlers.MerchHandler.CreateRule)
+	admin.Put("/merchandising/rules/:ruleId", can(models.PermMerchWrite), handlers.MerchHandler.UpdateRule)
+	admin.Delete("/merchandising/rules/:ruleId", can(models.PermMerchWrite), handlers.MerchHandler.DeleteRule)
+	admin.Get("/merchandising/audit", can(models.PermAnalytics), handlers.MerchHandler.ListAudit)
+
+	admin.Get("/retention", can(models.PermSettingsRead), handlers.PrivacyHandler.GetRetentionPolicy)
+	admin.Put("/retention", can(models.PermSettingsWrite), handlers.PrivacyHandler.UpdateRetentionPolicy)
+
+	admin.Get("/quota", can(models.PermAnalytics), handlers.QuotaHandler.GetQuota)
+	admin.Put("/quota/plan", can(models.PermPlatformManage), handlers.QuotaHandler.UpdatePlan)
+
+	admin.Get("/api-keys", can(models.PermKeysManage), handlers.APIKeyHandler.ListKeys)
+	admin.Post("/api-keys", can(models.PermKeysManage), handlers.APIKeyHandler.IssueKey)
+	admin.Post("/api-keys/:keyId/rotate", can(models.PermKeysManage), handlers.APIKeyHandler.RotateKey)
+	admin.Delete("/api-keys/:keyId", can(models.PermKeysManage), handlers.APIKeyHandler.RevokeKey)
+
+	admin.Get("/roles", can(models.PermRolesManage), handlers.RoleHandler.ListRoles)
+	admin.Put("/roles/:principal", can(models.PermRolesManage), handlers.RoleHandler.AssignRole)
+	admin.Delete("/roles/:principal", can(models.PermRolesManage), handlers.RoleHandler.RemoveRole)
+
+	admin.Get("/audit", can(models.PermAuditRead), handlers.AuditHandler.ListEvents)
 }
@@ -0,0 +1,83 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/weaviate/weaviate-go-client/v4/weaviate"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/graphql"
+)
+
+const backfillPageSize = 500
+
+// backfillProductKeys sets orgKey and productKey on products stored before
+// the keys existed. Products that already have them are skipped, so an
+// interrupted backfill finishes on the next start.
+func backfillProductKeys(ctx context.Context, client *weaviate.Client) error {
+	fields := []graphql.Field{
+		{Name: "orgId"},
+		{Name: "productId"},
+		{Name: "orgKey"},
+		{Name: "productKey"},
+		{Name: "_additional", Fields: []graphql.Field{{Name: "id"}}},
+	}
+
+	after := ""
+	updated := 0
+	for {
+		get := client.GraphQL().Get().
+			WithClassName("Product").
+			WithFields(fields...).
+			WithLimit(backfillPageSize)
+		if after != "" {
+			get = get.WithAfter(after)
+		}
+
+		resp, err := get.Do(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list products for key backfill: %w", err)
+		}
+		if len(resp.Errors) > 0 {
+			return fmt.Errorf("failed to list products for key backfill: %s", resp.Errors[0].Message)
+		}
+
+		root, _ := resp.Data["Get"].(map[string]any)
+		products, _ := root["Product"].([]any)
+		if len(products) == 0 {
+			break
+		}
+
+		for _, raw := range products {
+			props, _ := raw.(map[string]any)
+			additional, _ := props["_additional"].(map[string]any)
+			id, _ := additional["id"].(string)
+			if id == "" {
+				continue
+			}
+			after = id
+
+			orgID, _ := props["orgId"].(string)
+			productID, _ := props["productId"].(string)
+			if props["orgKey"] == orgID && props["productKey"] == productID {
+				continue
+			}
+
+			err := client.Data().Updater().
+				WithMerge().
+				WithClassName("Product").
+				WithID(id).
+				WithProperties(map[string]any{"orgKey": orgID, "productKey": productID}).
+				Do(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to backfill keys of product %s: %w", id, err)
+			}
+			updated++
+		}
+	}
+
+	if updated > 0 {
+		slog.Info("backfilled product keys", "count", updated)
+	}
+	return nil
+}
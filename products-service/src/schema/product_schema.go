@@ -3,6 +3,7 @@ package schema
 import (
 	"context"
 	"fmt"
+	"log/slog"
 
 	"github.com/weaviate/weaviate-go-client/v4/weaviate"
 	"github.com/weaviate/weaviate/entities/models"
@@ -19,8 +20,8 @@ func CreateProductClass(client *weaviate.Client) error {
 
 	for _, c := range existing.Classes {
 		if c.Class == "Product" {
-			fmt.Println("Class Product already exists, skipping creation")
-			return nil
+			slog.Debug("class Product already exists, skipping creation")
+			return ensureProductProperties(ctx, client, c)
 		}
 	}
 
@@ -255,5 +256,131 @@ func CreateProductClass(client *weaviate.Client) error {
 		},
 	}
 
+	productClass.Properties = append(productClass.Properties, addedProductProperties()...)
+
 	return client.Schema().ClassCreator().WithClass(productClass).Do(ctx)
 }
+
+// addedProductProperties are properties introduced after the Product class
+// was first rolled out. They are appended to new classes and created on
+// existing ones by ensureProductProperties.
+func addedProductProperties() []*models.Property {
+	return []*models.Property{
+		{
+			// orgKey and productKey copy orgId and productId with field
+			// tokenization so lookups match whole IDs. The originals use
+			// word tokenization, where prod-123 also matches prod-123-b.
+			Name:         "orgKey",
+			DataType:     []string{"text"},
+			Tokenization: models.PropertyTokenizationField,
+			ModuleConfig: map[string]interface{}{
+				"text2vec-transformers": map[string]interface{}{
+					"skip": true,
+				},
+			},
+		},
+		{
+			Name:         "productKey",
+			DataType:     []string{"text"},
+			Tokenization: models.PropertyTokenizationField,
+			ModuleConfig: map[string]interface{}{
+				"text2vec-transformers": map[string]interface{}{
+					"skip": true,
+				},
+			},
+		},
+		{
+			Name:     "minPrice",
+			DataType: []string{"number"},
+			ModuleConfig: map[string]interface{}{
+				"text2vec-transformers": map[string]interface{}{
+					"skip": true,
+				},
+			},
+		},
+		{
+			// Field tokenization keeps values like out_of_stock whole for
+			// filters.
+			Name:         "availability",
+			DataType:     []string{"text"},
+			Tokenization: models.PropertyTokenizationField,
+			ModuleConfig: map[string]interface{}{
+				"text2vec-transformers": map[string]interface{}{
+					"skip": true,
+				},
+			},
+		},
+		{
+			Name:     "attr_1_availability",
+			DataType: []string{"text"},
+			ModuleConfig: map[string]interface{}{
+				"text2vec-transformers": map[string]interface{}{
+					"skip": true,
+				},
+			},
+		},
+		{
+			Name:     "attr_2_availability",
+			DataType: []string{"text"},
+			ModuleConfig: map[string]interface{}{
+				"text2vec-transformers": map[string]interface{}{
+					"skip": true,
+				},
+			},
+		},
+		{
+			Name:     "attr_1_stock",
+			DataType: []string{"int"},
+			ModuleConfig: map[string]interface{}{
+				"text2vec-transformers": map[string]interface{}{
+					"skip": true,
+				},
+			},
+		},
+		{
+			Name:     "attr_2_stock",
+			DataType: []string{"int"},
+			ModuleConfig: map[string]interface{}{
+				"text2vec-transformers": map[string]interface{}{
+					"skip": true,
+				},
+			},
+		},
+		{
+			Name:     "attr_1_salePrice",
+			DataType: []string{"text"},
+			ModuleConfig: map[string]interface{}{
+				"text2vec-transformers": map[string]interface{}{
+					"skip": true,
+				},
+			},
+		},
+		{
+			Name:     "attr_2_salePrice",
+			DataType: []string{"text"},
+			ModuleConfig: map[string]interface{}{
+				"text2vec-transformers": map[string]interface{}{
+					"skip": true,
+				},
+			},
+		},
+	}
+}
+
+func ensureProductProperties(ctx context.Context, client *weaviate.Client, class *models.Class) error {
+	existing := make(map[string]bool, len(class.Properties))
+	for _, p := range class.Properties {
+		existing[p.Name] = true
+	}
+
+	for _, p := range addedProductProperties() {
+		if existing[p.Name] {
+			continue
+		}
+		err := client.Schema().PropertyCreator().WithClassName("Product").WithProperty(p).Do(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to add property %s: %w", p.Name, err)
+		}
+	}
+	return backfillProductKeys(ctx, client)
+}
@@ -1,45 +1,93 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Adityadangi14/ecomm_ai/config"
 	"github.com/Adityadangi14/ecomm_ai/pkg/WDB"
 	"github.com/Adityadangi14/ecomm_ai/pkg/redis"
+	"github.com/Adityadangi14/ecomm_ai/pkg/tracing"
 	"github.com/Adityadangi14/ecomm_ai/products-service/handlers"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/audit"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/auth"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/health"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/llm"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/metrics"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/mq"
+	"github.com/Adityadangi14/ecomm_ai/products-service/src/quota"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/repository"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/routes"
 	"github.com/Adityadangi14/ecomm_ai/products-service/src/schema"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	goredis "github.com/redis/go-redis/v9"
 	"github.com/streadway/amqp"
+	"github.com/valyala/fasthttp"
 )
 
+const (
+	// checkTimeout bounds each readiness check.
+	checkTimeout = 2 * time.Second
+	// llmCheckInterval limits how often the LLM provider is probed.
+	llmCheckInterval = time.Minute
+	// defaultShutdownTimeout applies when server.ShutdownTimeoutSec is unset.
+	defaultShutdownTimeout = 30 * time.Second
+	// streamWriteTimeout replaces server.WriteTimeout for answer streams,
+	// which write for as long as the model keeps generating.
+	streamWriteTimeout = 10 * time.Minute
+)
+
+// streamRoutes answer with server-sent events.
+var streamRoutes = []string{"/api/v1/response"}
+
 type Server struct {
-	db   *WDB.WDB
-	amqp *amqp.Connection
-	cfg  *config.Config
+	db      *WDB.WDB
+	amqp    *amqp.Connection
+	cfg     *config.Config
+	runtime *config.Runtime
+	log     *slog.Logger
 }
 
-func NewProductServer(wdb *WDB.WDB, mq *amqp.Connection, cfg *config.Config) *Server {
-	return &Server{db: wdb, amqp: mq, cfg: cfg}
+func NewProductServer(wdb *WDB.WDB, mq *amqp.Connection, cfg *config.Config, runtime *config.Runtime, log *slog.Logger) *Server {
+	return &Server{db: wdb, amqp: mq, cfg: cfg, runtime: runtime, log: log}
 }
 
-func (s *Server) Run() error {
+// Run serves the API and consumes the product queues until ctx is
+// cancelled, then shuts down gracefully.
+func (s *Server) Run(ctx context.Context) error {
 
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		ReadTimeout:       s.cfg.Server.ReadTimeout,
+		WriteTimeout:      s.cfg.Server.WriteTimeout,
+		IdleTimeout:       s.cfg.Server.MaxConnectionIdle,
+		EnablePrintRoutes: s.cfg.Server.Debug,
+	})
+	app.Server().HeaderReceived = streamTimeouts
+	app.Use(requestid.New())
+	app.Use(tracing.Middleware())
+	app.Use(metrics.HTTPMiddleware())
+	app.Use(requestTimeout(s.cfg.Server.CtxDefaultTimeout))
 
 	rdb, err := redis.ConnectToRedis(s.cfg)
 
 	if err != nil {
 		return fmt.Errorf("failed to connect to redis:%v", err)
 	}
+	rdb.AddHook(metrics.RedisHook{})
 
-	prodRepo := repository.NewProductRepository(s.db)
+	prodRepo := repository.NewProductRepository(s.db, rdb, s.runtime, s.log)
 
-	aiClient := llm.NewAiClient(rdb, prodRepo)
+	if err := llm.CheckTokenizer(s.runtime.Current().Models.ChatModel); err != nil {
+		return err
+	}
+
+	aiClient := llm.NewAiClient(rdb, prodRepo, s.cfg.LLM, s.runtime, s.log)
 
 	proPub, err := mq.NewProductsPublisher(s.amqp, s.cfg, aiClient)
 
@@ -50,7 +98,7 @@ func (s *Server) Run() error {
 	err = schema.CreateProductClass(s.db.DB)
 
 	if err != nil {
-		fmt.Println("Failed to create product class", err)
+		s.log.Error("failed to create product class", "err", err)
 	}
 
 	err = proPub.SetupExchangeAndQueue(s.cfg.RabbitMQ.Exchange,
@@ -59,16 +107,43 @@ func (s *Server) Run() error {
 		s.cfg.RabbitMQ.ConsumerTag)
 
 	if err != nil {
-		fmt.Println("Failed to setup exchange  and queue", err)
+		s.log.Error("failed to setup exchange and queue", "err", err)
+	}
+
+	err = proPub.SetupExchangeAndQueue(s.cfg.RabbitMQ.UpdatesExchange,
+		s.cfg.RabbitMQ.UpdatesQueue,
+		s.cfg.RabbitMQ.UpdatesRoutingKey,
+		s.cfg.RabbitMQ.UpdatesConsumerTag)
+
+	if err != nil {
+		s.log.Error("failed to setup updates exchange and queue", "err", err)
+	}
+
+	err = proPub.SetupExchangeAndQueue(s.cfg.RabbitMQ.AuditExchange,
+		s.cfg.RabbitMQ.AuditQueue,
+		s.cfg.RabbitMQ.AuditRoutingKey,
+		s.cfg.RabbitMQ.AuditConsumerTag)
+
+	if err != nil {
+		s.log.Error("failed to setup audit exchange and queue", "err", err)
 	}
 
-	//defer proPub.CloseChan()
+	limiter := quota.NewLimiter(rdb, s.runtime, s.log)
 
-	prodConu := mq.NewProductsConsumer(s.amqp, prodRepo, aiClient)
+	prodConu := mq.NewProductsConsumer(s.amqp, prodRepo, aiClient, limiter, rdb, s.log)
 
+	// Consumers stop on their own context so they can be stopped before the
+	// HTTP server has finished draining.
+	consumeCtx, stopConsuming := context.WithCancel(context.Background())
+	defer stopConsuming()
+	var consumers sync.WaitGroup
+
+	consumers.Add(3)
 	go func() {
+		defer consumers.Done()
 		err := prodConu.StartConsumer(
-			s.cfg.RabbitMQ.WorkerPoolSize,
+			consumeCtx,
+			s.runtime.Current().WorkerPoolSize,
 			s.cfg.RabbitMQ.Exchange,
 			s.cfg.RabbitMQ.Queue,
 			s.cfg.RabbitMQ.RoutingKey,
@@ -76,16 +151,191 @@ func (s *Server) Run() error {
 		)
 
 		if err != nil {
-			fmt.Println("failed to start product consumer: ", err)
+			s.log.Error("failed to start product consumer", "err", err)
+
+		}
+	}()
+
+	go func() {
+		defer consumers.Done()
+		err := prodConu.StartUpdateConsumer(
+			consumeCtx,
+			s.runtime.Current().UpdatesWorkerPoolSize,
+			s.cfg.RabbitMQ.UpdatesExchange,
+			s.cfg.RabbitMQ.UpdatesQueue,
+			s.cfg.RabbitMQ.UpdatesRoutingKey,
+			s.cfg.RabbitMQ.UpdatesConsumerTag,
+		)
+
+		if err != nil {
+			s.log.Error("failed to start product update consumer", "err", err)
+		}
+	}()
+
+	go func() {
+		defer consumers.Done()
+		err := prodConu.StartAuditConsumer(
+			consumeCtx,
+			s.cfg.RabbitMQ.AuditExchange,
+			s.cfg.RabbitMQ.AuditQueue,
+			s.cfg.RabbitMQ.AuditRoutingKey,
+			s.cfg.RabbitMQ.AuditConsumerTag,
+		)
 
+		if err != nil {
+			s.log.Error("failed to start audit consumer", "err", err)
 		}
 	}()
 
-	apiHandler := handlers.NewHandler(proPub, prodRepo, aiClient, rdb)
+	s.runtime.Subscribe(func(old, new config.RuntimeConfig) {
+		s.resizePool(prodConu, s.cfg.RabbitMQ.Queue, old.WorkerPoolSize, new.WorkerPoolSize)
+		s.resizePool(prodConu, s.cfg.RabbitMQ.UpdatesQueue, old.UpdatesWorkerPoolSize, new.UpdatesWorkerPoolSize)
+	})
+
+	authn, err := auth.NewAuthenticator(rdb, s.cfg.Auth, s.cfg.Server.JwtSecretKey)
+	if err != nil {
+		return fmt.Errorf("failed to set up authentication:%v", err)
+	}
 
-	routes.RegisterRoutes(app, *apiHandler)
+	checker := health.NewChecker(checkTimeout, s.readinessChecks(rdb, proPub, prodConu, aiClient)...)
 
-	log.Fatal(app.Listen(":3000"))
+	apiHandler := handlers.NewHandler(proPub, prodRepo, aiClient, rdb, s.cfg, s.runtime, authn, audit.NewRecorder(proPub, s.log), checker, s.log)
 
+	routes.RegisterRoutes(app, *apiHandler, limiter, authn)
+
+	listenErr := make(chan error, 1)
+	go func() {
+		if s.cfg.Server.SSL {
+			listenErr <- app.ListenTLS(s.cfg.Server.Port, s.cfg.Server.CertFile, s.cfg.Server.KeyFile)
+			return
+		}
+		listenErr <- app.Listen(s.cfg.Server.Port)
+	}()
+
+	select {
+	case err := <-listenErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.shutdown(app, stopConsuming, &consumers, prodConu)
+
+	// The publisher may be used until both HTTP handlers and workers are
+	// done. The AMQP connection itself is closed by the caller.
+	if err := proPub.CloseChan(); err != nil {
+		s.log.Error("failed to close publisher channel", "err", err)
+	}
+	if err := rdb.Close(); err != nil {
+		s.log.Error("failed to close redis", "err", err)
+	}
+	s.log.Info("server stopped")
 	return nil
 }
+
+// resizePool applies a changed worker pool size to the queue's consumer.
+func (s *Server) resizePool(consumer *mq.ProductConsumer, queue string, old, new int) {
+	if old == new {
+		return
+	}
+	if err := consumer.Resize(queue, new); err != nil {
+		s.log.Error("failed to resize worker pool", "queue", queue, "size", new, "err", err)
+	}
+}
+
+// requestTimeout bounds the context of each request. It is cancelled once
+// the handler returns, so work that outlives the request must detach from it.
+func requestTimeout(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+// streamTimeouts lifts the write timeout for stream routes.
+func streamTimeouts(header *fasthttp.RequestHeader) fasthttp.RequestConfig {
+	path := string(header.RequestURI())
+	for _, route := range streamRoutes {
+		if strings.HasPrefix(path, route) {
+			return fasthttp.RequestConfig{WriteTimeout: streamWriteTimeout}
+		}
+	}
+	return fasthttp.RequestConfig{}
+}
+
+// shutdown stops accepting requests and deliveries, then waits for open
+// streams and in-flight jobs. Jobs still running at the deadline are
+// aborted and their deliveries requeued.
+func (s *Server) shutdown(app *fiber.App, stopConsuming context.CancelFunc, consumers *sync.WaitGroup, prodConu *mq.ProductConsumer) {
+	timeout := defaultShutdownTimeout
+	if s.cfg.Server.ShutdownTimeoutSec > 0 {
+		timeout = time.Duration(s.cfg.Server.ShutdownTimeoutSec) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	s.log.Info("shutting down", "timeout", timeout.String())
+
+	stopConsuming()
+	consumersDone := make(chan struct{})
+	go func() {
+		consumers.Wait()
+		close(consumersDone)
+	}()
+
+	if err := app.ShutdownWithContext(ctx); err != nil {
+		s.log.Warn("http server did not drain in time", "err", err)
+	}
+
+	select {
+	case <-consumersDone:
+	case <-ctx.Done():
+		s.log.Warn("consumers did not drain in time, aborting in-flight jobs")
+		prodConu.Abort()
+		<-consumersDone
+	}
+}
+
+// readinessChecks covers every dependency a request or job needs. The LLM
+// provider is optional: answers degrade without it, but the catalog, search
+// and admin APIs still work.
+func (s *Server) readinessChecks(rdb *goredis.Client, pub *mq.Productpublisher, consumer *mq.ProductConsumer, aiClient llm.Aiclient) []health.Check {
+	consumerCheck := func(queue string) func(context.Context) error {
+		return func(context.Context) error {
+			if !consumer.Running(queue) {
+				return fmt.Errorf("consumer for %s is not running", queue)
+			}
+			return nil
+		}
+	}
+
+	return []health.Check{
+		{Name: "redis", Run: func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		}},
+		{Name: "weaviate", Run: func(ctx context.Context) error {
+			ready, err := s.db.DB.Misc().ReadyChecker().Do(ctx)
+			if err != nil {
+				return err
+			}
+			if !ready {
+				return errors.New("weaviate is not ready")
+			}
+			return nil
+		}},
+		{Name: "amqp", Run: func(context.Context) error {
+			if s.amqp.IsClosed() {
+				return errors.New("connection closed")
+			}
+			if !pub.ChannelOpen() {
+				return errors.New("publish channel closed")
+			}
+			return nil
+		}},
+		{Name: "consumer.products", Run: consumerCheck(s.cfg.RabbitMQ.Queue)},
+		{Name: "consumer.updates", Run: consumerCheck(s.cfg.RabbitMQ.UpdatesQueue)},
+		{Name: "consumer.audit", Run: consumerCheck(s.cfg.RabbitMQ.AuditQueue)},
+		{Name: "llm", Optional: true, CacheFor: llmCheckInterval, Run: aiClient.Ping},
+	}
+}